@@ -2,7 +2,9 @@ package catalogapptests
 
 import (
 	"fmt"
-	"path/filepath"
+	"os"
+
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/framework"
 )
 
 // App is something that can be installed on a cluster (e.g. Flux or a catalog app).
@@ -13,29 +15,93 @@ type App interface {
 // Ensure compile-time implementation (only fluxApp implements App; CatalogApp is installed by cluster.Install).
 var _ App = fluxApp{}
 
-// fluxApp installs Flux (source, kustomize, helm controllers) on a cluster.
-type fluxApp struct{}
+// FluxVersionEnv sets the Flux version NewFluxApp installs when WithFluxVersion is not given (e.g. the
+// version an NKP release ships); default "latest", or framework.DefaultFluxVersion when offline.
+const FluxVersionEnv = "FLUX_VERSION"
+
+// FluxOfflineEnv, when "true", makes NewFluxApp install Flux without network access (see WithFluxOffline).
+const FluxOfflineEnv = "FLUX_OFFLINE"
+
+// fluxApp installs Flux (source, kustomize, helm controllers by default) on a cluster.
+type fluxApp struct {
+	options framework.FluxOptions
+}
+
+// FluxOption configures the Flux installation of NewFluxApp.
+type FluxOption func(*framework.FluxOptions)
+
+// WithFluxVersion installs the given Flux release (e.g. "v2.6.4").
+func WithFluxVersion(version string) FluxOption {
+	return func(o *framework.FluxOptions) { o.Version = version }
+}
+
+// WithFluxNamespace installs Flux in namespace (default kommander-flux).
+func WithFluxNamespace(namespace string) FluxOption {
+	return func(o *framework.FluxOptions) { o.Namespace = namespace }
+}
+
+// WithFluxComponents adds controllers to source/kustomize/helm-controller
+// (e.g. "notification-controller", "image-reflector-controller", "image-automation-controller").
+func WithFluxComponents(components ...string) FluxOption {
+	return func(o *framework.FluxOptions) { o.ExtraComponents = append(o.ExtraComponents, components...) }
+}
+
+// WithFluxControllerFlags adds args to a controller (e.g. "helm-controller", "--concurrent=10").
+func WithFluxControllerFlags(component string, flags ...string) FluxOption {
+	return func(o *framework.FluxOptions) {
+		if o.ControllerFlags == nil {
+			o.ControllerFlags = map[string][]string{}
+		}
+		o.ControllerFlags[component] = append(o.ControllerFlags[component], flags...)
+	}
+}
+
+// WithFluxManifestsDir installs from a local copy of a Flux release's manifests instead of downloading them.
+func WithFluxManifestsDir(dir string) FluxOption {
+	return func(o *framework.FluxOptions) { o.ManifestsDir = dir }
+}
+
+// WithFluxOffline installs Flux without network access: manifests of the pinned version (default
+// framework.DefaultFluxVersion) come from WithFluxManifestsDir, the embedded set or the manifests cache, and
+// the controller images are side-loaded into the Kind nodes from the local Docker daemon or image cache.
+func WithFluxOffline() FluxOption {
+	return func(o *framework.FluxOptions) { o.Offline = true }
+}
+
+// WithFluxImageSideLoad side-loads the controller images into the Kind nodes instead of pulling them there.
+func WithFluxImageSideLoad() FluxOption {
+	return func(o *framework.FluxOptions) { o.SideLoadImages = true }
+}
 
 // NewFluxApp returns an App that installs Flux on a cluster.
-func NewFluxApp() App {
-	return fluxApp{}
+func NewFluxApp(opts ...FluxOption) App {
+	o := framework.FluxOptions{Version: os.Getenv(FluxVersionEnv), Offline: os.Getenv(FluxOfflineEnv) == "true"}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return fluxApp{options: o}
 }
 
 // FluxApp is the default Flux app instance for use in tests (e.g. cluster.Install(FluxApp)).
 var FluxApp App = NewFluxApp()
 
-func (fluxApp) InstallOn(cluster Cluster) error {
+func (f fluxApp) InstallOn(cluster Cluster) error {
 	impl, ok := cluster.(*clusterImpl)
 	if !ok {
 		return fmt.Errorf("FluxApp requires cluster from KindCluster")
 	}
-	return impl.installFlux(impl.ctx)
+	return impl.installFlux(impl.ctx, f.options)
 }
 
 // CatalogApp is an app from the catalog (e.g. podinfo). Use with cluster.Install(catalogApp).
 type CatalogApp struct {
 	AppName          string
 	VersionToInstall string // empty = latest
+	// Mode is how the app is delivered (InstallModeDirect or InstallModeGitOps); empty = DefaultInstallMode().
+	Mode InstallMode
+	// PreloadImages loads the version's ImagesFile into the cluster nodes before installing it
+	// (also enabled by PreloadImagesEnv).
+	PreloadImages bool
 }
 
 // NewCatalogApp returns a catalog app for the given name and version (version empty = latest).
@@ -48,6 +114,19 @@ func (c *CatalogApp) Name() string {
 	return c.AppName
 }
 
+// mode returns Mode, or DefaultInstallMode() when unset.
+func (c *CatalogApp) mode() InstallMode {
+	if c.Mode == "" {
+		return DefaultInstallMode()
+	}
+	return c.Mode
+}
+
+// preloadImages reports whether images are preloaded before installing (PreloadImages or PreloadImagesEnv).
+func (c *CatalogApp) preloadImages() bool {
+	return c.PreloadImages || os.Getenv(PreloadImagesEnv) == "true"
+}
+
 // Install installs this catalog app on the cluster (cluster.Install pattern).
 func (c *CatalogApp) Install(cluster Cluster) error {
 	return cluster.Install(c)
@@ -68,11 +147,7 @@ func (c *CatalogApp) InstallPreviousVersion(cluster Cluster) error {
 	if err != nil {
 		return err
 	}
-	helmreleasePath := filepath.Join(appPath, "helmrelease")
-	return cluster.ApplyKustomizations(cluster.Ctx(), helmreleasePath, map[string]string{
-		"releaseNamespace": DefaultNamespace,
-		"releaseName":      c.AppName,
-	})
+	return installCatalogVersion(cluster, c, appPath)
 }
 
 // Upgrade applies the latest version (for upgrade tests).
@@ -90,9 +165,5 @@ func (c *CatalogApp) Upgrade(cluster Cluster) error {
 	if err != nil {
 		return err
 	}
-	helmreleasePath := filepath.Join(appPath, "helmrelease")
-	return cluster.ApplyKustomizations(cluster.Ctx(), helmreleasePath, map[string]string{
-		"releaseNamespace": DefaultNamespace,
-		"releaseName":      c.AppName,
-	})
+	return installCatalogVersion(cluster, c, appPath)
 }
@@ -0,0 +1,214 @@
+package catalogapptests
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// CatalogSourceFile is the per-app file (applications/<app>/.catalog-source.yaml) declaring that the chart is
+// pulled from a Helm repo and pushed to OCI. See docs/CATALOG-SOURCE.md.
+const CatalogSourceFile = ".catalog-source.yaml"
+
+// CatalogSource is the typed content of .catalog-source.yaml.
+type CatalogSource struct {
+	// HelmRepo is repo_name/chart_name as used with helm search/pull (e.g. kyverno/kyverno).
+	HelmRepo string `json:"helmrepo"`
+	// HelmRepoURL is the upstream Helm repository URL.
+	HelmRepoURL string `json:"helmrepoUrl"`
+	// OCIPush is the OCI base path the chart is pushed to (e.g. oci://ghcr.io/org/kyverno).
+	OCIPush string `json:"ocipush"`
+}
+
+// LoadCatalogSource reads and validates a .catalog-source.yaml file.
+func LoadCatalogSource(path string) (*CatalogSource, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s CatalogSource
+	if err := yaml.UnmarshalStrict(b, &s); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if err := s.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &s, nil
+}
+
+// Validate checks that all fields are set and well-formed.
+func (s *CatalogSource) Validate() error {
+	var errs []error
+	if repo, chart, ok := strings.Cut(s.HelmRepo, "/"); !ok || repo == "" || chart == "" {
+		errs = append(errs, fmt.Errorf("helmrepo must be <repo>/<chart>, got %q", s.HelmRepo))
+	}
+	if u, err := url.Parse(s.HelmRepoURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, fmt.Errorf("helmrepoUrl must be an http(s) URL, got %q", s.HelmRepoURL))
+	}
+	if !strings.HasPrefix(s.OCIPush, "oci://") || len(s.OCIPush) == len("oci://") {
+		errs = append(errs, fmt.Errorf("ocipush must be an oci:// URL, got %q", s.OCIPush))
+	}
+	return errors.Join(errs...)
+}
+
+// RepoName returns the Helm repo name part of HelmRepo.
+func (s *CatalogSource) RepoName() string {
+	repo, _, _ := strings.Cut(s.HelmRepo, "/")
+	return repo
+}
+
+// ChartName returns the chart name part of HelmRepo.
+func (s *CatalogSource) ChartName() string {
+	_, chart, _ := strings.Cut(s.HelmRepo, "/")
+	return chart
+}
+
+// OCIChartURL returns the OCI URL the pushed chart is expected at: <ocipush>/<chart>.
+func (s *CatalogSource) OCIChartURL() string {
+	return strings.TrimSuffix(s.OCIPush, "/") + "/" + s.ChartName()
+}
+
+// ChartRef is the chart source referenced by an app version's helmrelease/helmrelease.yaml.
+type ChartRef struct {
+	// Kind is the Flux source kind: OCIRepository or HelmRepository.
+	Kind string
+	// URL is the source URL (oci://... or the Helm repository URL).
+	URL string
+	// Chart is the HelmRelease chart name (HelmRepository sources only).
+	Chart string
+	// Version is the OCIRepository ref.tag or the HelmRelease chart version.
+	Version string
+	// Type is the HelmRepository type ("oci" or empty for index-based repositories).
+	Type string
+	// Insecure is spec.insecure of the source (plain HTTP).
+	Insecure bool
+}
+
+// ErrNoChartRef is returned when a helmrelease declares neither an OCIRepository nor a HelmRepository chart.
+var ErrNoChartRef = errors.New("no chart source found")
+
+type chartSourceObject struct {
+	Kind string `json:"kind"`
+	Spec struct {
+		URL      string `json:"url"`
+		Type     string `json:"type"`
+		Insecure bool   `json:"insecure"`
+		Ref      struct {
+			Tag string `json:"tag"`
+		} `json:"ref"`
+		Chart struct {
+			Spec struct {
+				Chart   string `json:"chart"`
+				Version string `json:"version"`
+			} `json:"spec"`
+		} `json:"chart"`
+	} `json:"spec"`
+}
+
+// LoadChartRef reads the chart source from versionPath/helmrelease/helmrelease.yaml (first OCIRepository,
+// otherwise HelmRepository plus the HelmRelease chart name and version).
+func LoadChartRef(versionPath string) (*ChartRef, error) {
+	path := filepath.Join(versionPath, "helmrelease", "helmrelease.yaml")
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var oci, helmRepo, release *chartSourceObject
+	dec := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(b), 4096)
+	for {
+		var obj chartSourceObject
+		err := dec.Decode(&obj)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decode %s: %w", path, err)
+		}
+		o := obj
+		switch {
+		case obj.Kind == "OCIRepository" && oci == nil:
+			oci = &o
+		case obj.Kind == "HelmRepository" && helmRepo == nil:
+			helmRepo = &o
+		case obj.Kind == "HelmRelease" && release == nil:
+			release = &o
+		}
+	}
+	switch {
+	case oci != nil && oci.Spec.URL != "":
+		return &ChartRef{Kind: "OCIRepository", URL: oci.Spec.URL, Version: oci.Spec.Ref.Tag, Insecure: oci.Spec.Insecure}, nil
+	case helmRepo != nil && helmRepo.Spec.URL != "" && release != nil && release.Spec.Chart.Spec.Chart != "":
+		return &ChartRef{
+			Kind:     "HelmRepository",
+			URL:      helmRepo.Spec.URL,
+			Chart:    release.Spec.Chart.Spec.Chart,
+			Version:  release.Spec.Chart.Spec.Version,
+			Type:     helmRepo.Spec.Type,
+			Insecure: helmRepo.Spec.Insecure,
+		}, nil
+	}
+	return nil, fmt.Errorf("%s: %w", path, ErrNoChartRef)
+}
+
+// CatalogSource implements Catalog: returns the app's .catalog-source.yaml, or nil if the app has none.
+func (c *catalog) CatalogSource(appName string) (*CatalogSource, error) {
+	path := filepath.Join(c.basePath, appName, CatalogSourceFile)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil
+	}
+	return LoadCatalogSource(path)
+}
+
+// ValidateCatalogSource implements Catalog: checks that every version of the app references the declared
+// source — OCIRepository url <ocipush>/<chart>, or a HelmRepository at helmrepoUrl with the same chart.
+// Apps without .catalog-source.yaml are valid.
+func (c *catalog) ValidateCatalogSource(appName string) error {
+	src, err := c.CatalogSource(appName)
+	if err != nil || src == nil {
+		return err
+	}
+	apps, err := c.Apps()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, av := range apps {
+		if av.Name != appName {
+			continue
+		}
+		for _, v := range av.Versions {
+			ref, err := LoadChartRef(filepath.Join(c.basePath, appName, v))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s/%s: %w", appName, v, err))
+				continue
+			}
+			if err := src.matches(ref); err != nil {
+				errs = append(errs, fmt.Errorf("%s/%s: %w", appName, v, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *CatalogSource) matches(ref *ChartRef) error {
+	switch ref.Kind {
+	case "OCIRepository":
+		if ref.URL != s.OCIChartURL() {
+			return fmt.Errorf("OCIRepository url %s does not match %s %s", ref.URL, CatalogSourceFile, s.OCIChartURL())
+		}
+	case "HelmRepository":
+		if strings.TrimSuffix(ref.URL, "/") != strings.TrimSuffix(s.HelmRepoURL, "/") || ref.Chart != s.ChartName() {
+			return fmt.Errorf("HelmRepository %s chart %s does not match %s %s chart %s",
+				ref.URL, ref.Chart, CatalogSourceFile, s.HelmRepoURL, s.ChartName())
+		}
+	}
+	return nil
+}
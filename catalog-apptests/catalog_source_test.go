@@ -0,0 +1,166 @@
+package catalogapptests
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/internal/testutil"
+)
+
+const catalogSource = `helmrepo: kyverno/kyverno
+helmrepoUrl: https://kyverno.github.io/kyverno/
+ocipush: oci://registry.example.com/charts
+`
+
+// helmRepositoryRelease installs <chart> <version> from a HelmRepository at url.
+func helmRepositoryRelease(url, chart, version string) string {
+	return `apiVersion: source.toolkit.fluxcd.io/v1
+kind: HelmRepository
+metadata:
+  name: ${releaseName}-repo
+spec:
+  url: ` + url + `
+---
+apiVersion: helm.toolkit.fluxcd.io/v2
+kind: HelmRelease
+metadata:
+  name: ` + chart + `
+spec:
+  chart:
+    spec:
+      chart: ` + chart + `
+      version: ` + version + `
+      sourceRef:
+        kind: HelmRepository
+        name: ${releaseName}-repo
+`
+}
+
+var _ = Describe("CatalogSource", Label("unit"), func() {
+	var appsDir string
+
+	BeforeEach(func() {
+		appsDir = GinkgoT().TempDir()
+	})
+
+	Describe("LoadCatalogSource", func() {
+		It("loads a valid file", func() {
+			path := filepath.Join(appsDir, CatalogSourceFile)
+			testutil.WriteFile(path, catalogSource)
+			src, err := LoadCatalogSource(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(src.RepoName()).To(Equal("kyverno"))
+			Expect(src.ChartName()).To(Equal("kyverno"))
+			Expect(src.OCIChartURL()).To(Equal("oci://registry.example.com/charts/kyverno"))
+		})
+
+		It("rejects unknown fields", func() {
+			path := filepath.Join(appsDir, CatalogSourceFile)
+			testutil.WriteFile(path, catalogSource+"chart: kyverno\n")
+			_, err := LoadCatalogSource(path)
+			Expect(err).To(MatchError(ContainSubstring("parse " + path)))
+			Expect(err).To(MatchError(ContainSubstring(`unknown field "chart"`)))
+		})
+
+		It("returns the read error for a missing file", func() {
+			_, err := LoadCatalogSource(filepath.Join(appsDir, CatalogSourceFile))
+			Expect(err).To(MatchError(os.ErrNotExist))
+		})
+	})
+
+	Describe("Validate", func() {
+		DescribeTable("reports every malformed field",
+			func(src CatalogSource, want ...string) {
+				err := src.Validate()
+				if len(want) == 0 {
+					Expect(err).ToNot(HaveOccurred())
+					return
+				}
+				for _, w := range want {
+					Expect(err).To(MatchError(ContainSubstring(w)))
+				}
+			},
+			Entry("valid", CatalogSource{HelmRepo: "a/b", HelmRepoURL: "https://example.com", OCIPush: "oci://r/x"}),
+			Entry("helmrepo without chart", CatalogSource{HelmRepo: "a/", HelmRepoURL: "https://example.com", OCIPush: "oci://r/x"},
+				"helmrepo must be <repo>/<chart>"),
+			Entry("non-http helmrepoUrl", CatalogSource{HelmRepo: "a/b", HelmRepoURL: "oci://example.com", OCIPush: "oci://r/x"},
+				"helmrepoUrl must be an http(s) URL"),
+			Entry("empty ocipush", CatalogSource{HelmRepo: "a/b", HelmRepoURL: "https://example.com", OCIPush: "oci://"},
+				"ocipush must be an oci:// URL"),
+			Entry("all fields", CatalogSource{},
+				"helmrepo must be", "helmrepoUrl must be", "ocipush must be"),
+		)
+	})
+
+	Describe("LoadChartRef", func() {
+		It("prefers the OCIRepository", func() {
+			v := testutil.WriteApp(appsDir, "kyverno", "3.0.0", testutil.HelmRelease("kyverno", "kyverno", "3.0.0", ""), "")
+			ref, err := LoadChartRef(v)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*ref).To(Equal(ChartRef{Kind: "OCIRepository", URL: "oci://registry.example.com/charts/kyverno", Version: "3.0.0"}))
+		})
+
+		It("reads a HelmRepository with the HelmRelease chart and version", func() {
+			v := testutil.WriteApp(appsDir, "kyverno", "3.0.0", helmRepositoryRelease("https://kyverno.github.io/kyverno", "kyverno", "3.0.0"), "")
+			ref, err := LoadChartRef(v)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*ref).To(Equal(ChartRef{Kind: "HelmRepository", URL: "https://kyverno.github.io/kyverno", Chart: "kyverno", Version: "3.0.0"}))
+		})
+
+		It("returns ErrNoChartRef without a chart source", func() {
+			v := testutil.WriteApp(appsDir, "custom", "1.0.0", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: x\n", "")
+			_, err := LoadChartRef(v)
+			Expect(err).To(MatchError(ErrNoChartRef))
+		})
+
+		It("returns the read error for a missing helmrelease.yaml", func() {
+			_, err := LoadChartRef(filepath.Join(appsDir, "missing", "1.0.0"))
+			Expect(err).To(MatchError(os.ErrNotExist))
+		})
+	})
+
+	Describe("ValidateCatalogSource", func() {
+		validate := func(app string) error {
+			cat, err := NewCatalogAt(appsDir)
+			Expect(err).ToNot(HaveOccurred())
+			return cat.ValidateCatalogSource(app)
+		}
+
+		BeforeEach(func() {
+			testutil.WriteFile(filepath.Join(appsDir, "kyverno", CatalogSourceFile), catalogSource)
+		})
+
+		It("accepts versions matching the declared source", func() {
+			testutil.WriteApp(appsDir, "kyverno", "3.0.0", testutil.HelmRelease("kyverno", "kyverno", "3.0.0", ""), "")
+			testutil.WriteApp(appsDir, "kyverno", "3.1.0", helmRepositoryRelease("https://kyverno.github.io/kyverno", "kyverno", "3.1.0"), "")
+			Expect(validate("kyverno")).To(Succeed())
+		})
+
+		It("reports versions whose source does not match", func() {
+			testutil.WriteApp(appsDir, "kyverno", "3.0.0", testutil.HelmRelease("kyverno", "other", "3.0.0", ""), "")
+			testutil.WriteApp(appsDir, "kyverno", "3.1.0", helmRepositoryRelease("https://example.com/charts", "kyverno", "3.1.0"), "")
+			err := validate("kyverno")
+			Expect(err).To(MatchError(ContainSubstring("kyverno/3.0.0: OCIRepository url oci://registry.example.com/charts/other does not match")))
+			Expect(err).To(MatchError(ContainSubstring("kyverno/3.1.0: HelmRepository https://example.com/charts chart kyverno does not match")))
+		})
+
+		It("reports versions without a chart source", func() {
+			testutil.WriteApp(appsDir, "kyverno", "3.0.0", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: x\n", "")
+			Expect(validate("kyverno")).To(MatchError(ErrNoChartRef))
+		})
+
+		It("rejects an invalid .catalog-source.yaml", func() {
+			testutil.WriteFile(filepath.Join(appsDir, "kyverno", CatalogSourceFile), "helmrepo: kyverno\n")
+			testutil.WriteApp(appsDir, "kyverno", "3.0.0", testutil.HelmRelease("kyverno", "kyverno", "3.0.0", ""), "")
+			Expect(validate("kyverno")).To(MatchError(ContainSubstring("helmrepo must be <repo>/<chart>")))
+		})
+
+		It("accepts apps without .catalog-source.yaml", func() {
+			testutil.WriteApp(appsDir, "podinfo", "6.9.4", testutil.HelmRelease("podinfo", "other", "6.9.4", ""), "")
+			Expect(validate("podinfo")).To(Succeed())
+		})
+	})
+})
@@ -0,0 +1,207 @@
+package catalogapptests
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// BaseRefEnv is the environment variable holding the git ref (e.g. origin/main) the templated suite
+// compares against. When set, only apps whose applications/<app> tree or dependencies changed are tested.
+const BaseRefEnv = "CATALOG_BASE_REF"
+
+// appDependencies is the subset of metadata.yaml and helmrelease.yaml that declares dependencies on other apps.
+type appDependencies struct {
+	Dependencies         []string `json:"dependencies"`
+	RequiredDependencies []string `json:"requiredDependencies"`
+}
+
+type helmReleaseDependsOn struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec struct {
+		DependsOn []struct {
+			Name string `json:"name"`
+		} `json:"dependsOn"`
+	} `json:"spec"`
+}
+
+// ChangedApps implements Catalog: returns the apps whose applications/<app> tree differs from baseRef
+// (committed, uncommitted or untracked), plus every app that depends on one of them (transitively).
+func (c *catalog) ChangedApps(baseRef string) ([]AppVersions, error) {
+	if baseRef == "" {
+		return nil, fmt.Errorf("base ref is required")
+	}
+	apps, err := c.Apps()
+	if err != nil {
+		return nil, err
+	}
+	files, err := c.changedFiles(baseRef)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool, len(apps))
+	for _, av := range apps {
+		known[av.Name] = true
+	}
+	changed := make(map[string]bool)
+	for _, f := range files {
+		rel, err := filepath.Rel(c.basePath, f)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		name := strings.Split(filepath.ToSlash(rel), "/")[0]
+		if known[name] {
+			changed[name] = true
+		}
+	}
+
+	// HelmRelease spec.dependsOn names releases; map them to the apps declaring those HelmReleases.
+	refs := make(map[string]*appRefs, len(apps))
+	releaseApp := make(map[string]string)
+	for _, av := range apps {
+		r, err := c.refs(av)
+		if err != nil {
+			return nil, err
+		}
+		refs[av.Name] = r
+		for _, release := range r.releases {
+			releaseApp[release] = av.Name
+		}
+	}
+	// Reverse edges: dependency -> apps depending on it, so a change propagates to dependents.
+	dependents := make(map[string][]string)
+	for _, av := range apps {
+		deps := refs[av.Name].dependencies
+		for _, release := range refs[av.Name].dependsOn {
+			if app, ok := releaseApp[release]; ok {
+				deps = append(deps, app)
+			}
+		}
+		seen := make(map[string]bool)
+		for _, d := range deps {
+			if known[d] && d != av.Name && !seen[d] {
+				seen[d] = true
+				dependents[d] = append(dependents[d], av.Name)
+			}
+		}
+	}
+	queue := make([]string, 0, len(changed))
+	for name := range changed {
+		queue = append(queue, name)
+	}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, d := range dependents[name] {
+			if !changed[d] {
+				changed[d] = true
+				queue = append(queue, d)
+			}
+		}
+	}
+
+	var result []AppVersions
+	for _, av := range apps {
+		if changed[av.Name] {
+			result = append(result, av)
+		}
+	}
+	return result, nil
+}
+
+// changedFiles returns absolute paths of files that differ between the merge base of baseRef and the
+// working tree, including untracked files (e.g. a new version directory not yet committed).
+func (c *catalog) changedFiles(baseRef string) ([]string, error) {
+	top, err := gitOutput(c.basePath, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	root := strings.TrimSpace(top)
+	diff, err := gitOutput(root, "diff", "--name-only", "--merge-base", baseRef, "--", c.basePath)
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := gitOutput(root, "ls-files", "--others", "--exclude-standard", "--", c.basePath)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, line := range strings.Split(diff+"\n"+untracked, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			files = append(files, filepath.Join(root, filepath.FromSlash(line)))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// appRefs is what the versions of an app declare about dependencies and the HelmReleases they provide.
+type appRefs struct {
+	// dependencies are app names from metadata.yaml dependencies/requiredDependencies.
+	dependencies []string
+	// releases are the names of the app's HelmReleases.
+	releases []string
+	// dependsOn are HelmRelease names from the app's HelmRelease spec.dependsOn.
+	dependsOn []string
+}
+
+// refs reads the appRefs of av across all of its versions.
+func (c *catalog) refs(av AppVersions) (*appRefs, error) {
+	r := &appRefs{}
+	seen := make(map[string]bool)
+	add := func(list *[]string, kind string, names ...string) {
+		for _, n := range names {
+			if n != "" && !seen[kind+"/"+n] {
+				seen[kind+"/"+n] = true
+				*list = append(*list, n)
+			}
+		}
+	}
+	for _, v := range av.Versions {
+		versionPath := filepath.Join(c.basePath, av.Name, v)
+		if b, err := os.ReadFile(filepath.Join(versionPath, "metadata.yaml")); err == nil {
+			var md appDependencies
+			if err := yaml.Unmarshal(b, &md); err != nil {
+				return nil, fmt.Errorf("parse metadata for %s/%s: %w", av.Name, v, err)
+			}
+			add(&r.dependencies, "app", md.Dependencies...)
+			add(&r.dependencies, "app", md.RequiredDependencies...)
+		}
+		b, err := os.ReadFile(filepath.Join(versionPath, "helmrelease", "helmrelease.yaml"))
+		if err != nil {
+			continue
+		}
+		for _, doc := range bytes.Split(b, []byte("\n---")) {
+			var hr helmReleaseDependsOn
+			if err := yaml.Unmarshal(doc, &hr); err != nil || hr.Kind != "HelmRelease" {
+				continue
+			}
+			add(&r.releases, "release", hr.Metadata.Name)
+			for _, d := range hr.Spec.DependsOn {
+				add(&r.dependsOn, "dependsOn", d.Name)
+			}
+		}
+	}
+	return r, nil
+}
+
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
@@ -0,0 +1,102 @@
+package catalogapptests
+
+import (
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/internal/testutil"
+)
+
+var _ = Describe("ChangedApps", Label("unit"), func() {
+	var repo, appsDir string
+
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", repo, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		Expect(err).ToNot(HaveOccurred(), string(out))
+	}
+	// writeVersion writes applications/<app>/1.0.0 with a HelmRelease named release (dependsOn the given
+	// releases) and metadata dependencies.
+	writeVersion := func(app, release string, dependsOn []string, dependencies ...string) {
+		spec := ""
+		if len(dependsOn) > 0 {
+			spec = "dependsOn:\n"
+			for _, d := range dependsOn {
+				spec += "  - name: " + d + "\n"
+			}
+		}
+		dir := testutil.WriteApp(appsDir, app, "1.0.0", testutil.HelmRelease(release, app, "1.0.0", spec), "")
+		md := "displayName: " + app + "\n"
+		if len(dependencies) > 0 {
+			md += "dependencies:\n"
+			for _, d := range dependencies {
+				md += "  - " + d + "\n"
+			}
+		}
+		testutil.WriteFile(filepath.Join(dir, "metadata.yaml"), md)
+	}
+	changed := func() []string {
+		cat, err := NewCatalogAt(appsDir)
+		Expect(err).ToNot(HaveOccurred())
+		apps, err := cat.ChangedApps("main")
+		Expect(err).ToNot(HaveOccurred())
+		var names []string
+		for _, av := range apps {
+			names = append(names, av.Name)
+		}
+		return names
+	}
+
+	BeforeEach(func() {
+		repo = GinkgoT().TempDir()
+		appsDir = filepath.Join(repo, "applications")
+		git("init", "-q", "-b", "main")
+		// cert-manager's HelmRelease is named certs: dependsOn names it, never the app.
+		writeVersion("cert-manager", "certs", nil)
+		writeVersion("issuer", "issuer", []string{"certs"})
+		writeVersion("dashboard", "dashboard", nil, "issuer")
+		// A HelmRelease that happens to be named like an unrelated app.
+		writeVersion("podinfo", "podinfo", []string{"podinfo-db"})
+		writeVersion("podinfo-db", "database", nil)
+		writeVersion("standalone", "standalone", nil)
+		git("add", "-A")
+		git("commit", "-q", "-m", "catalog")
+		git("checkout", "-q", "-b", "topic")
+	})
+
+	It("returns nothing without changes", func() {
+		Expect(changed()).To(BeEmpty())
+	})
+
+	It("propagates a change to dependents through release names and metadata", func() {
+		testutil.WriteFile(filepath.Join(appsDir, "cert-manager", "1.0.0", "helmrelease", "cm.yaml"), "---\n")
+		Expect(changed()).To(Equal([]string{"cert-manager", "dashboard", "issuer"}))
+	})
+
+	It("does not match dependsOn names against app names", func() {
+		testutil.WriteFile(filepath.Join(appsDir, "podinfo-db", "1.0.0", "notes.txt"), "x\n")
+		git("add", "-A")
+		git("commit", "-q", "-m", "touch podinfo-db")
+		Expect(changed()).To(Equal([]string{"podinfo-db"}))
+	})
+
+	It("includes untracked version directories", func() {
+		writeVersion("newapp", "newapp", nil)
+		Expect(changed()).To(Equal([]string{"newapp"}))
+	})
+
+	It("diffs against the merge base, ignoring changes made on the base ref since", func() {
+		git("checkout", "-q", "main")
+		testutil.WriteFile(filepath.Join(appsDir, "standalone", "1.0.0", "notes.txt"), "main only\n")
+		git("add", "-A")
+		git("commit", "-q", "-m", "main moves on")
+		git("checkout", "-q", "topic")
+		testutil.WriteFile(filepath.Join(appsDir, "dashboard", "1.0.0", "notes.txt"), "topic\n")
+		git("add", "-A")
+		git("commit", "-q", "-m", "topic change")
+		Expect(changed()).To(Equal([]string{"dashboard"}))
+	})
+})
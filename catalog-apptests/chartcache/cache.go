@@ -0,0 +1,209 @@
+// Package chartcache is an on-disk cache of Helm charts referenced by catalog apps, so offline checks
+// (values validation, rendering) can load charts without network access.
+package chartcache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/registry"
+	"sigs.k8s.io/yaml"
+
+	catalogapptests "github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests"
+)
+
+// DirEnv overrides the cache directory (default: <user cache dir>/catalog-apptests/charts).
+const DirEnv = "CHART_CACHE_DIR"
+
+// ErrNotCached is returned by Get when the chart is not in the cache and pulling is disabled.
+var ErrNotCached = errors.New("chart not in cache")
+
+// Cache loads charts from a local directory, optionally pulling missing ones.
+type Cache interface {
+	// Get returns the chart for ref, pulling it into the cache first when enabled.
+	Get(ctx context.Context, ref *catalogapptests.ChartRef) (*chart.Chart, error)
+	// Path returns where the chart archive for ref is (or would be) stored.
+	Path(ref *catalogapptests.ChartRef) (string, error)
+}
+
+// Ensure cache implements Cache at compile time.
+var _ Cache = (*cache)(nil)
+
+type cache struct {
+	dir    string
+	pull   bool
+	client *http.Client
+}
+
+// Option configures a Cache.
+type Option func(*cache)
+
+// WithPull pulls charts missing from the cache (OCI registry or Helm repository index).
+func WithPull() Option {
+	return func(c *cache) { c.pull = true }
+}
+
+// WithHTTPClient sets the HTTP client used to pull from Helm repositories.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *cache) { c.client = client }
+}
+
+// NewCache returns a Cache rooted at dir. Empty dir uses DefaultDir().
+func NewCache(dir string, opts ...Option) (Cache, error) {
+	if dir == "" {
+		var err error
+		if dir, err = DefaultDir(); err != nil {
+			return nil, err
+		}
+	}
+	c := &cache{dir: dir, client: http.DefaultClient}
+	for _, o := range opts {
+		o(c)
+	}
+	return c, nil
+}
+
+// DefaultDir returns $CHART_CACHE_DIR or <user cache dir>/catalog-apptests/charts.
+func DefaultDir() (string, error) {
+	if d := os.Getenv(DirEnv); d != "" {
+		return d, nil
+	}
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "catalog-apptests", "charts"), nil
+}
+
+func (c *cache) Path(ref *catalogapptests.ChartRef) (string, error) {
+	if ref.Version == "" {
+		return "", fmt.Errorf("chart %s has no version", ref.URL)
+	}
+	u, err := url.Parse(ref.URL)
+	if err != nil {
+		return "", fmt.Errorf("chart url %s: %w", ref.URL, err)
+	}
+	name := ref.Chart
+	if name == "" {
+		name = path.Base(u.Path)
+	}
+	rel := filepath.Join(u.Host, filepath.FromSlash(strings.Trim(u.Path, "/")))
+	if ref.Kind == "HelmRepository" {
+		rel = filepath.Join(rel, name)
+	}
+	return filepath.Join(c.dir, rel, name+"-"+ref.Version+".tgz"), nil
+}
+
+func (c *cache) Get(ctx context.Context, ref *catalogapptests.ChartRef) (*chart.Chart, error) {
+	p, err := c.Path(ref)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(p); err == nil {
+		return loader.Load(p)
+	}
+	if !c.pull {
+		return nil, fmt.Errorf("%s %s (%s): %w", ref.URL, ref.Version, p, ErrNotCached)
+	}
+	var data []byte
+	if ref.Kind == "OCIRepository" || ref.Type == "oci" {
+		data, err = c.pullOCI(ref)
+	} else {
+		data, err = c.pullHelmRepo(ctx, ref)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return nil, err
+	}
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		return nil, err
+	}
+	return loader.LoadArchive(bytes.NewReader(data))
+}
+
+func (c *cache) pullOCI(ref *catalogapptests.ChartRef) ([]byte, error) {
+	var opts []registry.ClientOption
+	if ref.Insecure {
+		opts = append(opts, registry.ClientOptPlainHTTP())
+	}
+	client, err := registry.NewClient(opts...)
+	if err != nil {
+		return nil, err
+	}
+	target := strings.TrimPrefix(ref.URL, "oci://")
+	if ref.Kind == "HelmRepository" {
+		target = strings.TrimSuffix(target, "/") + "/" + ref.Chart
+	}
+	res, err := client.Pull(target+":"+ref.Version, registry.PullOptWithChart(true))
+	if err != nil {
+		return nil, fmt.Errorf("pull %s:%s: %w", target, ref.Version, err)
+	}
+	return res.Chart.Data, nil
+}
+
+type indexFile struct {
+	Entries map[string][]struct {
+		Version string   `json:"version"`
+		URLs    []string `json:"urls"`
+	} `json:"entries"`
+}
+
+func (c *cache) pullHelmRepo(ctx context.Context, ref *catalogapptests.ChartRef) ([]byte, error) {
+	indexURL := strings.TrimSuffix(ref.URL, "/") + "/index.yaml"
+	b, err := c.fetch(ctx, indexURL)
+	if err != nil {
+		return nil, err
+	}
+	var idx indexFile
+	if err := yaml.Unmarshal(b, &idx); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", indexURL, err)
+	}
+	for _, e := range idx.Entries[ref.Chart] {
+		if e.Version != ref.Version || len(e.URLs) == 0 {
+			continue
+		}
+		base, err := url.Parse(indexURL)
+		if err != nil {
+			return nil, err
+		}
+		u, err := base.Parse(e.URLs[0])
+		if err != nil {
+			return nil, err
+		}
+		return c.fetch(ctx, u.String())
+	}
+	return nil, fmt.Errorf("chart %s %s not found in %s", ref.Chart, ref.Version, indexURL)
+}
+
+func (c *cache) fetch(ctx context.Context, u string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch %s: %s", u, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
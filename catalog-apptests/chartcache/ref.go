@@ -0,0 +1,70 @@
+package chartcache
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	catalogapptests "github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests"
+)
+
+// RefFromHelmRelease resolves the chart a HelmRelease installs from the rendered helmrelease objects:
+// spec.chartRef to an OCIRepository, or spec.chart.spec.sourceRef to a HelmRepository.
+func RefFromHelmRelease(hr *unstructured.Unstructured, objs []*unstructured.Unstructured) (*catalogapptests.ChartRef, error) {
+	find := func(kind, name string) *unstructured.Unstructured {
+		for _, o := range objs {
+			if o.GetKind() == kind && o.GetName() == name {
+				return o
+			}
+		}
+		return nil
+	}
+	if kind, ok, _ := unstructured.NestedString(hr.Object, "spec", "chartRef", "kind"); ok {
+		name, _, _ := unstructured.NestedString(hr.Object, "spec", "chartRef", "name")
+		if kind != "OCIRepository" {
+			return nil, fmt.Errorf("HelmRelease %s: chartRef kind %s is not supported", hr.GetName(), kind)
+		}
+		src := find(kind, name)
+		if src == nil {
+			return nil, fmt.Errorf("HelmRelease %s: OCIRepository %s not found in kustomization", hr.GetName(), name)
+		}
+		u, _, _ := unstructured.NestedString(src.Object, "spec", "url")
+		tag := nestedScalar(src, "spec", "ref", "tag")
+		insecure, _, _ := unstructured.NestedBool(src.Object, "spec", "insecure")
+		return &catalogapptests.ChartRef{Kind: kind, URL: u, Version: tag, Insecure: insecure}, nil
+	}
+	chartName, ok, _ := unstructured.NestedString(hr.Object, "spec", "chart", "spec", "chart")
+	if !ok {
+		return nil, fmt.Errorf("HelmRelease %s: neither chartRef nor chart is set", hr.GetName())
+	}
+	kind, _, _ := unstructured.NestedString(hr.Object, "spec", "chart", "spec", "sourceRef", "kind")
+	name, _, _ := unstructured.NestedString(hr.Object, "spec", "chart", "spec", "sourceRef", "name")
+	if kind != "HelmRepository" {
+		return nil, fmt.Errorf("HelmRelease %s: chart sourceRef kind %s is not supported", hr.GetName(), kind)
+	}
+	src := find(kind, name)
+	if src == nil {
+		return nil, fmt.Errorf("HelmRelease %s: HelmRepository %s not found in kustomization", hr.GetName(), name)
+	}
+	version := nestedScalar(hr, "spec", "chart", "spec", "version")
+	u, _, _ := unstructured.NestedString(src.Object, "spec", "url")
+	typ, _, _ := unstructured.NestedString(src.Object, "spec", "type")
+	insecure, _, _ := unstructured.NestedBool(src.Object, "spec", "insecure")
+	return &catalogapptests.ChartRef{
+		Kind:     kind,
+		URL:      u,
+		Chart:    chartName,
+		Version:  version,
+		Type:     typ,
+		Insecure: insecure,
+	}, nil
+}
+
+// nestedScalar returns a string, number or bool field as a string (unquoted YAML tags such as 1.10 parse as numbers).
+func nestedScalar(obj *unstructured.Unstructured, fields ...string) string {
+	v, ok, _ := unstructured.NestedFieldNoCopy(obj.Object, fields...)
+	if !ok || v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
@@ -4,16 +4,18 @@ package catalogapptests
 //   - Network (framework.Network): Docker network clusters are created on.
 //   - Catalog: dm-nkp-gitops-app-catalog (applications/ discovery); used by Cluster to resolve and install catalog apps.
 //   - Cluster: uses Network and Catalog; has a Role (management | workload | standalone). Install behavior is per role:
-//     NKPManagementCluster (role=management): InstallCentralizedOpencost, CreateFromParent for workloads.
-//     NKPWorkloadCluster (role=workload): InstallOpencost.
-//   - App: installable unit (FluxApp, CatalogApp); Cluster.Install(app) dispatches by type.
+//     InstallForRole installs the app of a pair (placements.yaml) that belongs on the role: central apps on
+//     NKPManagementCluster (role=management), client apps on NKPWorkloadCluster (role=workload).
+//     NKPManagementCluster also creates workloads (CreateFromParent) and delivers catalog apps to them
+//     through its Flux (InstallOnWorkload).
+//   - App: installable unit (FluxApp, LoadBalancerApp, CatalogApp); Cluster.Install(app) dispatches by type.
 // ClusterConfig binds Network + optional Catalog + Name when creating a cluster.
 
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
-	"path/filepath"
 	"sync"
 
 	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/framework"
@@ -21,7 +23,7 @@ import (
 )
 
 // Cluster is a cluster that uses a Network and optionally a Catalog, and installs apps by Role.
-// Role determines which catalog apps are appropriate (e.g. InstallCentralizedOpencost on management, InstallOpencost on workload).
+// Role determines which catalog apps are appropriate (InstallForRole: central apps on management, client apps on workload).
 type Cluster interface {
 	Ctx() context.Context
 	Client() ctrlClient.Client
@@ -29,8 +31,28 @@ type Cluster interface {
 	Network() *framework.Network
 	// Role is the NKP cluster role (management, workload, standalone); install behavior is per role.
 	Role() ClusterRole
+	// KubernetesVersion is the version requested in ClusterConfig ("" = the creator's default).
+	KubernetesVersion() string
 	Install(app interface{}) error
+	// InstallForRole installs the app of pair that belongs on this cluster's Role (AppPair.AppFor).
+	InstallForRole(pair AppPair) error
+	// PreloadImages loads images into every node so pods start without pulling them (local Docker daemon,
+	// the shared image cache, or pulled once and cached; see framework.EnsureImage).
+	PreloadImages(ctx context.Context, images ...string) error
+	// PreloadImageArchive loads every image of a docker save or OCI archive into every node.
+	PreloadImageArchive(ctx context.Context, path string) error
 	ApplyKustomizations(ctx context.Context, path string, substitutions map[string]string) error
+	// PortForwardService forwards a local port to port of the Service (a ready pod behind it, like kubectl
+	// port-forward svc/name); Close the result when done. See framework.HTTPProbe for smoke requests.
+	PortForwardService(ctx context.Context, namespace, name string, port int) (*framework.PortForward, error)
+	// PortForwardPod forwards a local port to port of the pod.
+	PortForwardPod(ctx context.Context, namespace, name string, port int) (*framework.PortForward, error)
+	// InstallIngress installs the catalog's ingress controller (IngressAppName) on the NodePorts published by
+	// ClusterConfig.Ingress.
+	InstallIngress() error
+	// IngressClient returns an HTTP client that reaches the ingress controller for any hostname (see
+	// framework.NewIngressClient); the cluster must be created with ClusterConfig.Ingress.
+	IngressClient(ctx context.Context) (*http.Client, error)
 	Destroy()
 }
 
@@ -39,6 +61,18 @@ type ClusterConfig struct {
 	Network *framework.Network
 	Catalog Catalog // optional; nil => DefaultCatalog() used when installing catalog apps
 	Name    string
+	// KubernetesVersion pins the cluster version (e.g. "v1.33.1" or a full Kind node image); "" uses the
+	// creator's default. Workload clusters created from this cluster use the same version.
+	KubernetesVersion string
+	// RegistryMirrors, when set, makes the nodes pull docker.io, ghcr.io, quay.io and registry.k8s.io (the
+	// mirrored hosts) through these pull-through caches on the same network. Workload clusters inherit them.
+	RegistryMirrors *framework.RegistryMirrors
+	// IPFamily of the cluster ("" = IPv4); IPv6 and dual-stack need a Network with an IPv6 subnet.
+	// Workload clusters inherit it.
+	IPFamily framework.IPFamily
+	// Ingress publishes the ingress NodePorts (framework.IngressHTTPNodePort / IngressHTTPSNodePort) on host
+	// ports for InstallIngress and IngressClient. Workload clusters inherit it.
+	Ingress bool
 }
 
 // NKPManagementCluster is a management cluster that can have workload clusters created from it.
@@ -47,16 +81,26 @@ type ClusterConfig struct {
 type NKPManagementCluster interface {
 	Cluster
 	NetworkName() string
-	// InstallCentralizedOpencost installs the central/aggregator OpenCost on this management cluster.
-	InstallCentralizedOpencost() error
+	// ExposeServiceOnNetwork exposes port of the Service on a NodePort and returns the address workload
+	// clusters on the same Docker network reach it at (see framework.ExposeServiceOnNetwork).
+	ExposeServiceOnNetwork(ctx context.Context, namespace, name string, port int) (string, error)
+	// RegisterWorkloadCluster stores the workload cluster's peer kubeconfig in the Secret
+	// <workload>-kubeconfig (namespace default) on this cluster, for hub-and-spoke apps (Flux
+	// spec.kubeConfig, Karmada member clusters), and returns the Secret name. The workload must be a PeerCluster.
+	RegisterWorkloadCluster(ctx context.Context, workload NKPWorkloadCluster) (string, error)
+	// InstallOnWorkload installs the catalog app on the workload cluster through this cluster's Flux: its
+	// HelmReleases are applied here, in the namespace named after the workload, with spec.kubeConfig pointing
+	// at the workload. Returns that namespace (see RemoteReleaseDeployed). The workload must be a PeerCluster.
+	InstallOnWorkload(app *CatalogApp, workload NKPWorkloadCluster) (string, error)
 }
 
 // NKPWorkloadCluster is a workload cluster created from an NKP management cluster (CreateFromParent).
 // It is a Cluster; the name is for type clarity in tests (e.g. central on mgmt, clients on workloads).
 type NKPWorkloadCluster interface {
 	Cluster
-	// InstallOpencost installs the OpenCost client on this workload cluster.
-	InstallOpencost() error
+	// HTTPGetFromPod fetches url from a pod on this cluster and returns the body, proving in-cluster
+	// reachability (e.g. of an address from NKPManagementCluster.ExposeServiceOnNetwork).
+	HTTPGetFromPod(ctx context.Context, url string) (string, error)
 }
 
 // ClusterCreator creates a new cluster on the given network. Used by CreateFromParent.
@@ -65,12 +109,47 @@ type ClusterCreator interface {
 	CreateCluster(ctx context.Context, networkName, name string) (ClusterHandle, error)
 }
 
+// ConfigurableClusterCreator is a ClusterCreator that applies the creation settings of a ClusterConfig
+// (KubernetesVersion, RegistryMirrors, IPFamily, Ingress). Used instead of CreateCluster when any of them is set.
+type ConfigurableClusterCreator interface {
+	ClusterCreator
+	CreateClusterWithConfig(ctx context.Context, networkName, name string, config ClusterConfig) (ClusterHandle, error)
+}
+
 // ClusterHandle is the infra-specific handle (kubeconfig path, delete). Implemented by framework.KindCluster.
 type ClusterHandle interface {
 	KubeconfigFilePath() string
 	Delete(ctx context.Context) error
 }
 
+// PeerKubeconfigProvider is a ClusterHandle whose cluster other clusters on the same network can reach.
+// Required of workload clusters by RegisterWorkloadCluster and Karmada.Join.
+type PeerKubeconfigProvider interface {
+	ClusterHandle
+	// PeerKubeconfig returns a kubeconfig that reaches the cluster from other clusters on the same network.
+	PeerKubeconfig() ([]byte, error)
+}
+
+// Ensure framework.KindCluster implements PeerKubeconfigProvider at compile time.
+var _ PeerKubeconfigProvider = (*framework.KindCluster)(nil)
+
+// PeerCluster is a cluster that other clusters on its Docker network reach through a peer kubeconfig.
+// RegisterWorkloadCluster, InstallOnWorkload and Karmada.Join require it of their workload cluster; clusters
+// from KindCluster.CreateFromParent implement it (PeerKubeconfig fails unless their handle is a
+// PeerKubeconfigProvider).
+type PeerCluster interface {
+	// Name is the cluster name; the Secret, namespace and Karmada member of a workload are named after it.
+	Name() string
+	// NetworkName is the Docker network the cluster runs on.
+	NetworkName() string
+	Client() ctrlClient.Client
+	// PeerKubeconfig returns a kubeconfig that reaches the cluster from other clusters on the same network.
+	PeerKubeconfig() ([]byte, error)
+}
+
+// Ensure clusterImpl implements PeerCluster at compile time.
+var _ PeerCluster = (*clusterImpl)(nil)
+
 // KindCluster creates clusters: Create(ctx, network, name) for mgmt or standalone;
 // CreateFromParent(ctx, mgmt, name) for one or more workloads. Mgmt must be an NKPManagementCluster.
 var KindCluster = &kindCluster{creator: defaultKindCreator{}}
@@ -82,10 +161,67 @@ type kindCluster struct {
 // defaultKindCreator creates Kind clusters on the given Docker network.
 type defaultKindCreator struct{}
 
+// Ensure defaultKindCreator implements ConfigurableClusterCreator at compile time.
+var _ ConfigurableClusterCreator = defaultKindCreator{}
+
 func (defaultKindCreator) CreateCluster(ctx context.Context, networkName, name string) (ClusterHandle, error) {
 	return framework.NewKindClusterInNetwork(ctx, name, networkName)
 }
 
+// CreateClusterWithConfig creates a Kind cluster with config's node image (loaded for KubernetesVersion from
+// the local Docker daemon, the node image cache or a pull),
+// registry mirrors, IP family and ingress ports. Registry mirrors must run on the cluster's network
+// (networkName, or Kind's default network when empty).
+func (defaultKindCreator) CreateClusterWithConfig(ctx context.Context, networkName, name string, config ClusterConfig) (ClusterHandle, error) {
+	if config.RegistryMirrors != nil {
+		clusterNetwork := networkName
+		if clusterNetwork == "" {
+			clusterNetwork = framework.GetDockerNetworkName()
+		}
+		if mirrorsNetwork := config.RegistryMirrors.Network(); mirrorsNetwork != clusterNetwork {
+			return nil, fmt.Errorf("cluster %s: registry mirrors run on network %q, not on the cluster network %q", name, mirrorsNetwork, clusterNetwork)
+		}
+	}
+	var opts []framework.KindOption
+	if config.KubernetesVersion != "" {
+		image := framework.NodeImageForVersion(config.KubernetesVersion)
+		if err := framework.EnsureNodeImage(ctx, image, ""); err != nil {
+			return nil, err
+		}
+		opts = append(opts, framework.WithNodeImage(image))
+	}
+	if config.RegistryMirrors != nil {
+		opts = append(opts, framework.WithRegistryMirrors(config.RegistryMirrors.Mirrors()...))
+	}
+	if config.IPFamily != "" {
+		opts = append(opts, framework.WithIPFamily(config.IPFamily))
+	}
+	if config.Ingress {
+		opts = append(opts, framework.WithIngressPorts())
+	}
+	if networkName == "" || networkName == "kind" {
+		return framework.NewKindCluster(ctx, name, opts...)
+	}
+	return framework.NewKindClusterInNetwork(ctx, name, networkName, opts...)
+}
+
+// hasCreateSettings reports whether config needs more than ClusterCreator.CreateCluster.
+func (config ClusterConfig) hasCreateSettings() bool {
+	return config.KubernetesVersion != "" || config.RegistryMirrors != nil || config.IPFamily != "" || config.Ingress
+}
+
+// createCluster creates a cluster with the creator, applying config's creation settings when set.
+func (k *kindCluster) createCluster(ctx context.Context, networkName, name string, config ClusterConfig) (ClusterHandle, error) {
+	if !config.hasCreateSettings() {
+		return k.creator.CreateCluster(ctx, networkName, name)
+	}
+	cc, ok := k.creator.(ConfigurableClusterCreator)
+	if !ok {
+		return nil, fmt.Errorf("cluster creator %T does not support the requested ClusterConfig settings", k.creator)
+	}
+	return cc.CreateClusterWithConfig(ctx, networkName, name, config)
+}
+
 // Create creates one cluster from config (uses config.Network and config.Catalog). Use for mgmt or standalone.
 // The cluster can be used as a parent: pass it to CreateFromParent(ctx, cluster, "workload1"), etc.
 func (k *kindCluster) Create(ctx context.Context, config ClusterConfig) (Cluster, error) {
@@ -107,9 +243,12 @@ func (k *kindCluster) Create(ctx context.Context, config ClusterConfig) (Cluster
 
 	var handle ClusterHandle
 	var err error
-	if config.Network != nil && config.Network.Name != "" && config.Network.Name != "kind" {
-		handle, err = k.creator.CreateCluster(ctx, networkName, name)
-	} else {
+	switch {
+	case config.Network != nil && config.Network.Name != "" && config.Network.Name != "kind":
+		handle, err = k.createCluster(ctx, networkName, name, config)
+	case config.hasCreateSettings():
+		handle, err = k.createCluster(ctx, "", name, config)
+	default:
 		handle, err = k.createStandalone(ctx, name)
 	}
 	if err != nil {
@@ -135,6 +274,10 @@ func (k *kindCluster) Create(ctx context.Context, config ClusterConfig) (Cluster
 		network:     config.Network,
 		networkName: networkName,
 		role:        role,
+		k8sVersion:  config.KubernetesVersion,
+		mirrors:     config.RegistryMirrors,
+		ipFamily:    config.IPFamily,
+		ingress:     config.Ingress,
 		children:    make(map[string]*clusterImpl),
 		destroy:     func() { _ = handle.Delete(ctx) },
 	}
@@ -167,7 +310,12 @@ func (k *kindCluster) CreateFromParent(ctx context.Context, mgmt NKPManagementCl
 	}
 	pi.mu.Unlock()
 
-	handle, err := k.creator.CreateCluster(ctx, pi.networkName, workloadName)
+	handle, err := k.createCluster(ctx, pi.networkName, workloadName, ClusterConfig{
+		KubernetesVersion: pi.k8sVersion,
+		RegistryMirrors:   pi.mirrors,
+		IPFamily:          pi.ipFamily,
+		Ingress:           pi.ingress,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -187,6 +335,10 @@ func (k *kindCluster) CreateFromParent(ctx context.Context, mgmt NKPManagementCl
 		network:     pi.network,
 		networkName: pi.networkName,
 		role:        ClusterRoleWorkload,
+		k8sVersion:  pi.k8sVersion,
+		mirrors:     pi.mirrors,
+		ipFamily:    pi.ipFamily,
+		ingress:     pi.ingress,
 		destroy:     func() { _ = handle.Delete(ctx) },
 	}
 
@@ -200,7 +352,7 @@ func (k *kindCluster) CreateFromParent(ctx context.Context, mgmt NKPManagementCl
 	parentHandle := pi.handle
 	pi.destroy = func() {
 		for _, c := range children {
-			_ = c.handle.Delete(ctx)
+			c.Destroy()
 		}
 		_ = parentHandle.Delete(ctx)
 	}
@@ -218,17 +370,36 @@ type clusterImpl struct {
 	network     *framework.Network
 	networkName string
 	role        ClusterRole
+	k8sVersion  string
+	mirrors     *framework.RegistryMirrors
+	ipFamily    framework.IPFamily
+	ingress     bool
+	registry    *framework.Registry // started on first GitOps install
 	children    map[string]*clusterImpl
 	mu          sync.Mutex
 	destroy     func()
 }
 
+func (c *clusterImpl) Name() string              { return c.name }
 func (c *clusterImpl) Ctx() context.Context      { return c.ctx }
 func (c *clusterImpl) Client() ctrlClient.Client { return c.client }
 func (c *clusterImpl) Catalog() Catalog             { return c.catalog }
 func (c *clusterImpl) Network() *framework.Network { return c.network }
 func (c *clusterImpl) Role() ClusterRole           { return c.role }
-func (c *clusterImpl) Destroy()                    { if c.destroy != nil { c.destroy() } }
+func (c *clusterImpl) KubernetesVersion() string   { return c.k8sVersion }
+
+func (c *clusterImpl) Destroy() {
+	c.mu.Lock()
+	reg := c.registry
+	c.registry = nil
+	c.mu.Unlock()
+	if reg != nil {
+		_ = reg.Delete(c.ctx)
+	}
+	if c.destroy != nil {
+		c.destroy()
+	}
+}
 
 func (c *clusterImpl) Install(app interface{}) error {
 	switch a := app.(type) {
@@ -241,6 +412,14 @@ func (c *clusterImpl) Install(app interface{}) error {
 	}
 }
 
+func (c *clusterImpl) InstallForRole(pair AppPair) error {
+	appName, err := pair.AppFor(c.role)
+	if err != nil {
+		return err
+	}
+	return c.Install(NewCatalogApp(appName, ""))
+}
+
 func (c *clusterImpl) installCatalogApp(app *CatalogApp) error {
 	cat := c.catalog
 	if cat == nil {
@@ -254,20 +433,50 @@ func (c *clusterImpl) installCatalogApp(app *CatalogApp) error {
 	if err != nil {
 		return err
 	}
-	helmreleasePath := filepath.Join(appPath, "helmrelease")
-	return c.ApplyKustomizations(c.ctx, helmreleasePath, map[string]string{
-		"releaseNamespace": DefaultNamespace,
-		"releaseName":      app.AppName,
-	})
+	return installCatalogVersion(c, app, appPath)
 }
 
 func (c *clusterImpl) NetworkName() string                  { return c.networkName }
-func (c *clusterImpl) InstallCentralizedOpencost() error { return c.Install(NewCatalogApp(MulticlusterCentralAppName, "")) }
-func (c *clusterImpl) InstallOpencost() error            { return c.Install(NewCatalogApp(MulticlusterTestAppName, "")) }
 func (c *clusterImpl) ApplyKustomizations(ctx context.Context, path string, substitutions map[string]string) error {
 	return framework.ApplyKustomizations(ctx, c.client, path, substitutions)
 }
 
-func (c *clusterImpl) installFlux(ctx context.Context) error {
-	return framework.InstallFlux(ctx, c.handle.KubeconfigFilePath(), "")
+func (c *clusterImpl) PreloadImages(ctx context.Context, images ...string) error {
+	name, err := c.kindClusterName()
+	if err != nil {
+		return err
+	}
+	return framework.LoadImagesIntoKind(ctx, name, images, "")
+}
+
+func (c *clusterImpl) PreloadImageArchive(ctx context.Context, path string) error {
+	name, err := c.kindClusterName()
+	if err != nil {
+		return err
+	}
+	return framework.LoadImageArchiveIntoKind(ctx, name, path)
+}
+
+func (c *clusterImpl) PortForwardService(ctx context.Context, namespace, name string, port int) (*framework.PortForward, error) {
+	return framework.PortForwardService(ctx, c.handle.KubeconfigFilePath(), namespace, name, port)
+}
+
+func (c *clusterImpl) PortForwardPod(ctx context.Context, namespace, name string, port int) (*framework.PortForward, error) {
+	return framework.PortForwardPod(ctx, c.handle.KubeconfigFilePath(), namespace, name, port)
+}
+
+// kindClusterName returns the Kind cluster name of the handle (handles from other creators have none).
+func (c *clusterImpl) kindClusterName() (string, error) {
+	named, ok := c.handle.(interface{ Name() string })
+	if !ok {
+		return "", fmt.Errorf("cluster %s: handle %T is not a Kind cluster", c.name, c.handle)
+	}
+	return named.Name(), nil
+}
+
+func (c *clusterImpl) installFlux(ctx context.Context, options framework.FluxOptions) error {
+	if name, err := c.kindClusterName(); err == nil && options.KindClusterName == "" {
+		options.KindClusterName = name
+	}
+	return framework.InstallFluxWithOptions(ctx, c.handle.KubeconfigFilePath(), options)
 }
@@ -0,0 +1,92 @@
+package catalogapptests
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	ctrlClient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/framework"
+)
+
+var _ = Describe("CreateClusterWithConfig", Label("unit"), func() {
+	// The zero RegistryMirrors reports an empty network, which no cluster network matches.
+	config := ClusterConfig{RegistryMirrors: &framework.RegistryMirrors{}}
+
+	It("rejects registry mirrors outside the cluster network", func() {
+		_, err := defaultKindCreator{}.CreateClusterWithConfig(context.Background(), "catalog-net", "c1", config)
+		Expect(err).To(MatchError(`cluster c1: registry mirrors run on network "", not on the cluster network "catalog-net"`))
+	})
+
+	It("compares against Kind's default network without a custom one", func() {
+		GinkgoT().Setenv("KIND_EXPERIMENTAL_DOCKER_NETWORK", "")
+		_, err := defaultKindCreator{}.CreateClusterWithConfig(context.Background(), "", "c1", config)
+		Expect(err).To(MatchError(ContainSubstring(`not on the cluster network "kind"`)))
+	})
+})
+
+// kubeconfigOnlyHandle is a ClusterHandle that is not a PeerKubeconfigProvider.
+type kubeconfigOnlyHandle struct{}
+
+func (kubeconfigOnlyHandle) KubeconfigFilePath() string       { return "" }
+func (kubeconfigOnlyHandle) Delete(ctx context.Context) error { return nil }
+
+// peerWorkload is an NKPWorkloadCluster that is not a clusterImpl but implements PeerCluster.
+type peerWorkload struct {
+	NKPWorkloadCluster
+	name string
+}
+
+func (w peerWorkload) Name() string                    { return w.name }
+func (w peerWorkload) NetworkName() string             { return "catalog-net" }
+func (w peerWorkload) Client() ctrlClient.Client       { return nil }
+func (w peerWorkload) PeerKubeconfig() ([]byte, error) { return []byte("peer"), nil }
+
+var _ = Describe("RegisterWorkloadCluster", Label("unit"), func() {
+	It("stores the peer kubeconfig of any PeerCluster", func() {
+		c := fake.NewClientBuilder().WithScheme(framework.NewScheme()).Build()
+		mgmt := &clusterImpl{name: "mgmt", networkName: "catalog-net", client: c}
+		name, err := mgmt.RegisterWorkloadCluster(context.Background(), peerWorkload{name: "w1"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(name).To(Equal("w1" + WorkloadKubeconfigSecretSuffix))
+		secret := &corev1.Secret{}
+		Expect(c.Get(context.Background(), ctrlClient.ObjectKey{Namespace: DefaultNamespace, Name: name}, secret)).To(Succeed())
+		Expect(secret.Data).To(HaveKeyWithValue(KubeconfigSecretKey, []byte("peer")))
+	})
+
+	It("rejects workloads that are not a PeerCluster", func() {
+		mgmt := &clusterImpl{name: "mgmt", networkName: "catalog-net"}
+		_, err := mgmt.RegisterWorkloadCluster(context.Background(), struct{ NKPWorkloadCluster }{})
+		Expect(err).To(MatchError(ContainSubstring("does not implement PeerCluster")))
+	})
+
+	It("rejects workload handles without a peer kubeconfig", func() {
+		mgmt := &clusterImpl{name: "mgmt", networkName: "catalog-net"}
+		workload := &clusterImpl{name: "w1", networkName: "catalog-net", handle: kubeconfigOnlyHandle{}}
+		_, err := mgmt.RegisterWorkloadCluster(context.Background(), workload)
+		Expect(err).To(MatchError(ContainSubstring("cluster w1: catalogapptests.kubeconfigOnlyHandle does not provide a peer kubeconfig")))
+	})
+})
+
+// plainCreator is a ClusterCreator without ConfigurableClusterCreator.
+type plainCreator struct{}
+
+func (plainCreator) CreateCluster(ctx context.Context, networkName, name string) (ClusterHandle, error) {
+	return kubeconfigOnlyHandle{}, nil
+}
+
+var _ = Describe("createCluster", Label("unit"), func() {
+	k := &kindCluster{creator: plainCreator{}}
+
+	It("uses CreateCluster without creation settings", func() {
+		Expect(k.createCluster(context.Background(), "kind", "c1", ClusterConfig{})).To(Equal(kubeconfigOnlyHandle{}))
+	})
+
+	It("rejects creation settings a plain ClusterCreator cannot apply", func() {
+		_, err := k.createCluster(context.Background(), "kind", "c1", ClusterConfig{KubernetesVersion: "v1.33.1"})
+		Expect(err).To(MatchError("cluster creator catalogapptests.plainCreator does not support the requested ClusterConfig settings"))
+	})
+})
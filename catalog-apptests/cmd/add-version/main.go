@@ -0,0 +1,80 @@
+// Command add-version creates applications/<app>/<version> from the app's latest version: it copies
+// the tree, rewrites the OCIRepository ref.tag / HelmRelease chart version and version strings,
+// checks metadata.yaml and runs the offline render check. When the new chart version is in the chart
+// cache (or --pull is set), the HelmReleases are rendered with Helm as well.
+//
+// Usage (from catalog-apptests/):
+//
+//	go run ./cmd/add-version --appname podinfo --version 6.9.5
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	catalogapptests "github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests"
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/chartcache"
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/scaffold"
+)
+
+func main() {
+	appName := flag.String("appname", "", "application name (required)")
+	version := flag.String("version", "", "new chart version (required)")
+	from := flag.String("from", "", "version to copy (default: latest)")
+	appsDir := flag.String("applications", "", "path to applications/ (default: discovered from cwd)")
+	skipRender := flag.Bool("skip-render", false, "skip the offline render check")
+	cacheDir := flag.String("cache-dir", "", "chart cache directory (default: $"+chartcache.DirEnv+" or the user cache dir)")
+	pull := flag.Bool("pull", false, "pull the new chart version into the cache to render it")
+	flag.Parse()
+
+	if *appName == "" || *version == "" {
+		fmt.Fprintln(os.Stderr, "Error: --appname and --version are required")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	var cat catalogapptests.Catalog
+	var err error
+	if *appsDir != "" {
+		cat, err = catalogapptests.NewCatalogAt(*appsDir)
+	} else {
+		cat, err = catalogapptests.NewCatalog()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: catalog:", err)
+		os.Exit(1)
+	}
+
+	var opts []scaffold.Option
+	if *from != "" {
+		opts = append(opts, scaffold.WithFromVersion(*from))
+	}
+	if *skipRender {
+		opts = append(opts, scaffold.WithoutRenderCheck())
+	}
+	var cacheOpts []chartcache.Option
+	if *pull {
+		cacheOpts = append(cacheOpts, chartcache.WithPull())
+	}
+	cache, err := chartcache.NewCache(*cacheDir, cacheOpts...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: chart cache:", err)
+		os.Exit(1)
+	}
+	opts = append(opts, scaffold.WithChartCache(cache))
+	r, err := scaffold.NewScaffolder(cat, opts...).NewVersion(context.Background(), *appName, *version)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Created %s (from %s)\n", r.Path, r.FromVersion)
+	for _, f := range r.Rewritten {
+		fmt.Printf("  updated %s\n", f)
+	}
+	if !r.Rendered && !*skipRender {
+		fmt.Println("Chart not in the cache; Helm render skipped (re-run with --pull to render it).")
+	}
+	fmt.Println("Review metadata.yaml and the defaults ConfigMap, then run ./catalog-workflow.sh validate.")
+}
@@ -0,0 +1,119 @@
+// Command check-deprecations renders catalog app versions offline for one or more Kubernetes versions and
+// reports deprecated or removed apiVersions, i.e. which app versions break on which cluster version.
+//
+// Usage (from catalog-apptests/):
+//
+//	go run ./cmd/check-deprecations --all --kube-version v1.32,v1.33,v1.34 --pull
+//	go run ./cmd/check-deprecations --appname kyverno --kube-version 1.34
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	catalogapptests "github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests"
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/chartcache"
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/deprecations"
+)
+
+func main() {
+	appName := flag.String("appname", "", "check only this application")
+	all := flag.Bool("all", false, "check all versions of all applications in applications/")
+	version := flag.String("version", "", "version to check with --appname (default: latest)")
+	kubeVersions := flag.String("kube-version", "", "comma-separated target Kubernetes versions (required)")
+	appsDir := flag.String("applications", "", "path to applications/ (default: discovered from cwd)")
+	cacheDir := flag.String("cache-dir", "", "chart cache directory (default: $"+chartcache.DirEnv+" or the user cache dir)")
+	pull := flag.Bool("pull", false, "pull charts missing from the cache")
+	timeout := flag.Duration("timeout", 10*time.Minute, "overall timeout")
+	flag.Parse()
+
+	if (!*all && *appName == "") || *kubeVersions == "" {
+		fmt.Fprintln(os.Stderr, "Error: specify --appname <name> or --all, and --kube-version")
+		os.Exit(1)
+	}
+	failed, err := run(*appsDir, *cacheDir, *appName, *version, strings.Split(*kubeVersions, ","), *pull, *timeout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func run(appsDir, cacheDir, appName, version string, kubeVersions []string, pull bool, timeout time.Duration) (bool, error) {
+	var cat catalogapptests.Catalog
+	var err error
+	if appsDir != "" {
+		cat, err = catalogapptests.NewCatalogAt(appsDir)
+	} else {
+		cat, err = catalogapptests.NewCatalog()
+	}
+	if err != nil {
+		return false, fmt.Errorf("catalog: %w", err)
+	}
+	var cacheOpts []chartcache.Option
+	if pull {
+		cacheOpts = append(cacheOpts, chartcache.WithPull())
+	}
+	cache, err := chartcache.NewCache(cacheDir, cacheOpts...)
+	if err != nil {
+		return false, fmt.Errorf("chart cache: %w", err)
+	}
+	var scanners []deprecations.Scanner
+	for _, kv := range kubeVersions {
+		s, err := deprecations.NewScanner(cat, cache, strings.TrimSpace(kv))
+		if err != nil {
+			return false, err
+		}
+		scanners = append(scanners, s)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var targets []catalogapptests.AppVersions
+	if appName != "" {
+		targets = []catalogapptests.AppVersions{{Name: appName, Versions: []string{version}}}
+	} else if targets, err = cat.Apps(); err != nil {
+		return false, err
+	}
+	fmt.Println("=== Check deprecated APIs ===")
+	fmt.Println()
+	failed := false
+	for _, app := range targets {
+		for _, v := range app.Versions {
+			label := app.Name
+			if v != "" {
+				label += "/" + v
+			}
+			for _, s := range scanners {
+				findings, err := s.Scan(ctx, app.Name, v)
+				switch {
+				case errors.Is(err, chartcache.ErrNotCached):
+					fmt.Printf("  %s on %s: skipped: %v (re-run with --pull)\n", label, s.KubeVersion(), err)
+				case err != nil:
+					fmt.Printf("  %s on %s: error: %v\n", label, s.KubeVersion(), err)
+					failed = true
+				case len(findings) == 0:
+					fmt.Printf("  %s on %s: ok\n", label, s.KubeVersion())
+				default:
+					for _, f := range findings {
+						if f.Status == deprecations.StatusRemoved {
+							failed = true
+						}
+						fmt.Printf("  %s\n", f)
+					}
+				}
+			}
+		}
+	}
+	fmt.Println()
+	fmt.Println("Done.")
+	return failed, nil
+}
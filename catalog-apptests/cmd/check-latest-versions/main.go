@@ -0,0 +1,101 @@
+// Command check-latest-versions reports newer upstream chart versions for catalog apps and
+// recommends add-app commands (Go port of scripts/check-latest-versions.sh).
+//
+// Usage (from catalog-apptests/):
+//
+//	go run ./cmd/check-latest-versions --all
+//	go run ./cmd/check-latest-versions --appname podinfo
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	catalogapptests "github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests"
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/versioncheck"
+)
+
+func main() {
+	appName := flag.String("appname", "", "check only this application")
+	all := flag.Bool("all", false, "check all applications in applications/")
+	appsDir := flag.String("applications", "", "path to applications/ (default: discovered from cwd)")
+	prereleases := flag.Bool("prereleases", false, "include pre-release versions")
+	timeout := flag.Duration("timeout", 5*time.Minute, "overall timeout")
+	flag.Parse()
+
+	if !*all && *appName == "" {
+		fmt.Fprintln(os.Stderr, "Error: specify --appname <name> or --all")
+		os.Exit(1)
+	}
+	if err := run(*appsDir, *appName, *prereleases, *timeout); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+func run(appsDir, appName string, prereleases bool, timeout time.Duration) error {
+	var cat catalogapptests.Catalog
+	var err error
+	if appsDir != "" {
+		cat, err = catalogapptests.NewCatalogAt(appsDir)
+	} else {
+		cat, err = catalogapptests.NewCatalog()
+	}
+	if err != nil {
+		return fmt.Errorf("catalog: %w", err)
+	}
+	var opts []versioncheck.Option
+	if prereleases {
+		opts = append(opts, versioncheck.WithPrereleases())
+	}
+	checker := versioncheck.NewChecker(cat, opts...)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	fmt.Println("=== Check latest versions ===")
+	fmt.Println()
+	if appName != "" {
+		r, err := checker.Check(ctx, appName)
+		printResult(r, err)
+	} else {
+		results, errs, err := checker.CheckAll(ctx)
+		if err != nil {
+			return err
+		}
+		for _, r := range results {
+			printResult(r, nil)
+		}
+		names := make([]string, 0, len(errs))
+		for n := range errs {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			printResult(versioncheck.Result{App: n}, errs[n])
+		}
+	}
+	fmt.Println("Done.")
+	return nil
+}
+
+func printResult(r versioncheck.Result, err error) {
+	switch {
+	case err != nil:
+		fmt.Printf("  %s: could not fetch latest version: %v\n", r.App, err)
+	case r.UpToDate():
+		fmt.Printf("  %s: up to date (catalog: %s, upstream: %s)\n", r.App, r.Current, r.Latest)
+	default:
+		fmt.Printf("  %s: newer version available\n", r.App)
+		fmt.Printf("    Current (catalog): %s  →  Latest (upstream): %s\n", r.Current, r.Latest)
+		if len(r.Newer) > 1 {
+			fmt.Printf("    Newer versions: %v\n", r.Newer)
+		}
+		fmt.Println("    Run:")
+		fmt.Printf("    %s\n\n", r.Command())
+	}
+}
@@ -0,0 +1,119 @@
+// Command check-policy renders catalog app versions offline and evaluates them against the catalog policy set
+// (catalog-apptests/policy/policies.yaml), honouring applications/<app>/.catalog-policy.yaml exceptions.
+//
+// Usage (from catalog-apptests/):
+//
+//	go run ./cmd/check-policy --all --pull
+//	go run ./cmd/check-policy --appname kubearmor --version v1.6.3
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	catalogapptests "github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests"
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/chartcache"
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/policy"
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/render"
+)
+
+func main() {
+	appName := flag.String("appname", "", "check only this application")
+	all := flag.Bool("all", false, "check all versions of all applications in applications/")
+	version := flag.String("version", "", "version to check with --appname (default: latest)")
+	appsDir := flag.String("applications", "", "path to applications/ (default: discovered from cwd)")
+	cacheDir := flag.String("cache-dir", "", "chart cache directory (default: $"+chartcache.DirEnv+" or the user cache dir)")
+	pull := flag.Bool("pull", false, "pull charts missing from the cache")
+	policies := flag.String("policies", "", "policy set file (default: the embedded policy/policies.yaml)")
+	timeout := flag.Duration("timeout", 10*time.Minute, "overall timeout")
+	flag.Parse()
+
+	if !*all && *appName == "" {
+		fmt.Fprintln(os.Stderr, "Error: specify --appname <name> or --all")
+		os.Exit(1)
+	}
+	failed, err := run(*appsDir, *cacheDir, *policies, *appName, *version, *pull, *timeout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func run(appsDir, cacheDir, policies, appName, version string, pull bool, timeout time.Duration) (bool, error) {
+	var cat catalogapptests.Catalog
+	var err error
+	if appsDir != "" {
+		cat, err = catalogapptests.NewCatalogAt(appsDir)
+	} else {
+		cat, err = catalogapptests.NewCatalog()
+	}
+	if err != nil {
+		return false, fmt.Errorf("catalog: %w", err)
+	}
+	var cacheOpts []chartcache.Option
+	if pull {
+		cacheOpts = append(cacheOpts, chartcache.WithPull())
+	}
+	cache, err := chartcache.NewCache(cacheDir, cacheOpts...)
+	if err != nil {
+		return false, fmt.Errorf("chart cache: %w", err)
+	}
+	var opts []policy.Option
+	if policies != "" {
+		ps, err := policy.LoadPolicySet(policies)
+		if err != nil {
+			return false, err
+		}
+		opts = append(opts, policy.WithPolicySet(ps))
+	}
+	checker, err := policy.NewChecker(cat, render.NewRenderer(cat, cache), opts...)
+	if err != nil {
+		return false, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var targets []catalogapptests.AppVersions
+	if appName != "" {
+		targets = []catalogapptests.AppVersions{{Name: appName, Versions: []string{version}}}
+	} else if targets, err = cat.Apps(); err != nil {
+		return false, err
+	}
+	fmt.Println("=== Check policies ===")
+	fmt.Println()
+	failed := false
+	for _, app := range targets {
+		for _, v := range app.Versions {
+			label := app.Name
+			if v != "" {
+				label += "/" + v
+			}
+			violations, err := checker.Check(ctx, app.Name, v)
+			switch {
+			case errors.Is(err, chartcache.ErrNotCached):
+				fmt.Printf("  %s: skipped: %v (re-run with --pull)\n", label, err)
+			case err != nil:
+				fmt.Printf("  %s: error: %v\n", label, err)
+				failed = true
+			case len(violations) == 0:
+				fmt.Printf("  %s: ok\n", label)
+			default:
+				failed = true
+				for _, viol := range violations {
+					fmt.Printf("  %s\n", viol)
+				}
+			}
+		}
+	}
+	fmt.Println()
+	fmt.Println("Done.")
+	return failed, nil
+}
@@ -0,0 +1,106 @@
+// Command check-values validates each app's ${releaseName}-config-defaults values against its chart
+// (values.schema.json when present, otherwise unknown top-level keys), using the local chart cache.
+//
+// Usage (from catalog-apptests/):
+//
+//	go run ./cmd/check-values --all --pull
+//	go run ./cmd/check-values --appname podinfo --version 6.9.2
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	catalogapptests "github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests"
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/chartcache"
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/valuescheck"
+)
+
+func main() {
+	appName := flag.String("appname", "", "check only this application")
+	all := flag.Bool("all", false, "check the latest version of all applications in applications/")
+	version := flag.String("version", "", "version to check with --appname (default: latest)")
+	appsDir := flag.String("applications", "", "path to applications/ (default: discovered from cwd)")
+	cacheDir := flag.String("cache-dir", "", "chart cache directory (default: $"+chartcache.DirEnv+" or the user cache dir)")
+	pull := flag.Bool("pull", false, "pull charts missing from the cache")
+	timeout := flag.Duration("timeout", 10*time.Minute, "overall timeout")
+	flag.Parse()
+
+	if !*all && *appName == "" {
+		fmt.Fprintln(os.Stderr, "Error: specify --appname <name> or --all")
+		os.Exit(1)
+	}
+	failed, err := run(*appsDir, *cacheDir, *appName, *version, *pull, *timeout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func run(appsDir, cacheDir, appName, version string, pull bool, timeout time.Duration) (bool, error) {
+	var cat catalogapptests.Catalog
+	var err error
+	if appsDir != "" {
+		cat, err = catalogapptests.NewCatalogAt(appsDir)
+	} else {
+		cat, err = catalogapptests.NewCatalog()
+	}
+	if err != nil {
+		return false, fmt.Errorf("catalog: %w", err)
+	}
+	var opts []chartcache.Option
+	if pull {
+		opts = append(opts, chartcache.WithPull())
+	}
+	cache, err := chartcache.NewCache(cacheDir, opts...)
+	if err != nil {
+		return false, fmt.Errorf("chart cache: %w", err)
+	}
+	checker := valuescheck.NewChecker(cat, cache)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	apps := []string{appName}
+	if appName == "" {
+		all, err := cat.Apps()
+		if err != nil {
+			return false, err
+		}
+		apps = apps[:0]
+		for _, a := range all {
+			apps = append(apps, a.Name)
+		}
+		version = ""
+	}
+	fmt.Println("=== Check default values ===")
+	fmt.Println()
+	failed := false
+	for _, app := range apps {
+		findings, err := checker.Check(ctx, app, version)
+		switch {
+		case errors.Is(err, chartcache.ErrNotCached):
+			fmt.Printf("  %s: skipped: %v (re-run with --pull)\n", app, err)
+		case err != nil:
+			fmt.Printf("  %s: error: %v\n", app, err)
+			failed = true
+		case len(findings) == 0:
+			fmt.Printf("  %s: ok\n", app)
+		default:
+			failed = true
+			for _, f := range findings {
+				fmt.Printf("  %s\n", f)
+			}
+		}
+	}
+	fmt.Println()
+	fmt.Println("Done.")
+	return failed, nil
+}
@@ -0,0 +1,83 @@
+// Command render prints the Kubernetes manifests an app version produces once Flux has reconciled it,
+// without a cluster: helmrelease kustomization objects plus each HelmRelease's chart rendered offline.
+//
+// Usage (from catalog-apptests/):
+//
+//	go run ./cmd/render --appname podinfo --pull
+//	go run ./cmd/render --appname cert-manager --version v1.19.2 --kube-version v1.33.1
+//	go run ./cmd/render --appname podinfo --images > ../applications/podinfo/6.9.4/images.txt
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	catalogapptests "github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests"
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/chartcache"
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/render"
+)
+
+func main() {
+	appName := flag.String("appname", "", "application to render (required)")
+	version := flag.String("version", "", "version to render (default: latest)")
+	appsDir := flag.String("applications", "", "path to applications/ (default: discovered from cwd)")
+	cacheDir := flag.String("cache-dir", "", "chart cache directory (default: $"+chartcache.DirEnv+" or the user cache dir)")
+	pull := flag.Bool("pull", false, "pull charts missing from the cache")
+	namespace := flag.String("namespace", catalogapptests.DefaultNamespace, "value of ${releaseNamespace}")
+	kubeVersion := flag.String("kube-version", "", "Kubernetes version for .Capabilities.KubeVersion")
+	images := flag.Bool("images", false, "print the image inventory (one image per line) instead of manifests")
+	timeout := flag.Duration("timeout", 5*time.Minute, "overall timeout")
+	flag.Parse()
+
+	if *appName == "" {
+		fmt.Fprintln(os.Stderr, "Error: --appname is required")
+		os.Exit(1)
+	}
+	if err := run(*appsDir, *cacheDir, *appName, *version, *namespace, *kubeVersion, *pull, *images, *timeout); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+func run(appsDir, cacheDir, appName, version, namespace, kubeVersion string, pull, images bool, timeout time.Duration) error {
+	var cat catalogapptests.Catalog
+	var err error
+	if appsDir != "" {
+		cat, err = catalogapptests.NewCatalogAt(appsDir)
+	} else {
+		cat, err = catalogapptests.NewCatalog()
+	}
+	if err != nil {
+		return fmt.Errorf("catalog: %w", err)
+	}
+	var cacheOpts []chartcache.Option
+	if pull {
+		cacheOpts = append(cacheOpts, chartcache.WithPull())
+	}
+	cache, err := chartcache.NewCache(cacheDir, cacheOpts...)
+	if err != nil {
+		return fmt.Errorf("chart cache: %w", err)
+	}
+	opts := []render.Option{render.WithNamespace(namespace)}
+	if kubeVersion != "" {
+		opts = append(opts, render.WithKubeVersion(kubeVersion))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	res, err := render.NewRenderer(cat, cache, opts...).Render(ctx, appName, version)
+	if err != nil {
+		return err
+	}
+	if images {
+		for _, image := range res.Images() {
+			fmt.Println(image)
+		}
+		return nil
+	}
+	return render.WriteYAML(os.Stdout, res.Objects())
+}
@@ -5,11 +5,6 @@ import "time"
 const (
 	DefaultNamespace = "default"
 	PollInterval     = 2 * time.Second
-
-	// MulticlusterTestAppName is the app installed on workload clusters (client).
-	MulticlusterTestAppName = "opencost"
-	// MulticlusterCentralAppName is the app installed on mgmt (central/aggregator).
-	MulticlusterCentralAppName = "centralized-opencost"
 )
 
 // ClusterRole is the NKP role of the cluster; determines which catalog apps are installed (e.g. central on mgmt, client on workload).
@@ -0,0 +1,87 @@
+package deprecations
+
+// API is a Kubernetes group/version/kind with the release it was deprecated in and, if any, removed in.
+type API struct {
+	Group        string
+	Version      string
+	Kind         string
+	DeprecatedIn string
+	RemovedIn    string
+	// Replacement is the apiVersion to migrate to.
+	Replacement string
+}
+
+// APIVersion returns group/version (version only for the core group).
+func (a API) APIVersion() string {
+	if a.Group == "" {
+		return a.Version
+	}
+	return a.Group + "/" + a.Version
+}
+
+// KnownAPIs lists deprecated and removed built-in APIs (Kubernetes deprecated API migration guide).
+var KnownAPIs = []API{
+	// v1.16
+	{"extensions", "v1beta1", "Deployment", "v1.9.0", "v1.16.0", "apps/v1"},
+	{"extensions", "v1beta1", "DaemonSet", "v1.9.0", "v1.16.0", "apps/v1"},
+	{"extensions", "v1beta1", "ReplicaSet", "v1.9.0", "v1.16.0", "apps/v1"},
+	{"extensions", "v1beta1", "NetworkPolicy", "v1.9.0", "v1.16.0", "networking.k8s.io/v1"},
+	{"extensions", "v1beta1", "PodSecurityPolicy", "v1.10.0", "v1.16.0", "policy/v1beta1"},
+	{"apps", "v1beta1", "Deployment", "v1.9.0", "v1.16.0", "apps/v1"},
+	{"apps", "v1beta1", "StatefulSet", "v1.9.0", "v1.16.0", "apps/v1"},
+	{"apps", "v1beta1", "ControllerRevision", "v1.9.0", "v1.16.0", "apps/v1"},
+	{"apps", "v1beta2", "Deployment", "v1.9.0", "v1.16.0", "apps/v1"},
+	{"apps", "v1beta2", "StatefulSet", "v1.9.0", "v1.16.0", "apps/v1"},
+	{"apps", "v1beta2", "DaemonSet", "v1.9.0", "v1.16.0", "apps/v1"},
+	{"apps", "v1beta2", "ReplicaSet", "v1.9.0", "v1.16.0", "apps/v1"},
+	{"apps", "v1beta2", "ControllerRevision", "v1.9.0", "v1.16.0", "apps/v1"},
+
+	// v1.22
+	{"extensions", "v1beta1", "Ingress", "v1.14.0", "v1.22.0", "networking.k8s.io/v1"},
+	{"networking.k8s.io", "v1beta1", "Ingress", "v1.19.0", "v1.22.0", "networking.k8s.io/v1"},
+	{"networking.k8s.io", "v1beta1", "IngressClass", "v1.19.0", "v1.22.0", "networking.k8s.io/v1"},
+	{"apiextensions.k8s.io", "v1beta1", "CustomResourceDefinition", "v1.16.0", "v1.22.0", "apiextensions.k8s.io/v1"},
+	{"admissionregistration.k8s.io", "v1beta1", "MutatingWebhookConfiguration", "v1.16.0", "v1.22.0", "admissionregistration.k8s.io/v1"},
+	{"admissionregistration.k8s.io", "v1beta1", "ValidatingWebhookConfiguration", "v1.16.0", "v1.22.0", "admissionregistration.k8s.io/v1"},
+	{"apiregistration.k8s.io", "v1beta1", "APIService", "v1.19.0", "v1.22.0", "apiregistration.k8s.io/v1"},
+	{"authentication.k8s.io", "v1beta1", "TokenReview", "v1.19.0", "v1.22.0", "authentication.k8s.io/v1"},
+	{"authorization.k8s.io", "v1beta1", "SubjectAccessReview", "v1.19.0", "v1.22.0", "authorization.k8s.io/v1"},
+	{"authorization.k8s.io", "v1beta1", "LocalSubjectAccessReview", "v1.19.0", "v1.22.0", "authorization.k8s.io/v1"},
+	{"authorization.k8s.io", "v1beta1", "SelfSubjectAccessReview", "v1.19.0", "v1.22.0", "authorization.k8s.io/v1"},
+	{"certificates.k8s.io", "v1beta1", "CertificateSigningRequest", "v1.19.0", "v1.22.0", "certificates.k8s.io/v1"},
+	{"coordination.k8s.io", "v1beta1", "Lease", "v1.19.0", "v1.22.0", "coordination.k8s.io/v1"},
+	{"rbac.authorization.k8s.io", "v1beta1", "ClusterRole", "v1.17.0", "v1.22.0", "rbac.authorization.k8s.io/v1"},
+	{"rbac.authorization.k8s.io", "v1beta1", "ClusterRoleBinding", "v1.17.0", "v1.22.0", "rbac.authorization.k8s.io/v1"},
+	{"rbac.authorization.k8s.io", "v1beta1", "Role", "v1.17.0", "v1.22.0", "rbac.authorization.k8s.io/v1"},
+	{"rbac.authorization.k8s.io", "v1beta1", "RoleBinding", "v1.17.0", "v1.22.0", "rbac.authorization.k8s.io/v1"},
+	{"scheduling.k8s.io", "v1beta1", "PriorityClass", "v1.14.0", "v1.22.0", "scheduling.k8s.io/v1"},
+	{"storage.k8s.io", "v1beta1", "CSIDriver", "v1.19.0", "v1.22.0", "storage.k8s.io/v1"},
+	{"storage.k8s.io", "v1beta1", "CSINode", "v1.17.0", "v1.22.0", "storage.k8s.io/v1"},
+	{"storage.k8s.io", "v1beta1", "StorageClass", "v1.19.0", "v1.22.0", "storage.k8s.io/v1"},
+	{"storage.k8s.io", "v1beta1", "VolumeAttachment", "v1.19.0", "v1.22.0", "storage.k8s.io/v1"},
+
+	// v1.25
+	{"batch", "v1beta1", "CronJob", "v1.21.0", "v1.25.0", "batch/v1"},
+	{"discovery.k8s.io", "v1beta1", "EndpointSlice", "v1.21.0", "v1.25.0", "discovery.k8s.io/v1"},
+	{"events.k8s.io", "v1beta1", "Event", "v1.22.0", "v1.25.0", "events.k8s.io/v1"},
+	{"autoscaling", "v2beta1", "HorizontalPodAutoscaler", "v1.22.0", "v1.25.0", "autoscaling/v2"},
+	{"policy", "v1beta1", "PodDisruptionBudget", "v1.21.0", "v1.25.0", "policy/v1"},
+	{"policy", "v1beta1", "PodSecurityPolicy", "v1.21.0", "v1.25.0", ""},
+	{"node.k8s.io", "v1beta1", "RuntimeClass", "v1.20.0", "v1.25.0", "node.k8s.io/v1"},
+
+	// v1.26
+	{"flowcontrol.apiserver.k8s.io", "v1beta1", "FlowSchema", "v1.23.0", "v1.26.0", "flowcontrol.apiserver.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io", "v1beta1", "PriorityLevelConfiguration", "v1.23.0", "v1.26.0", "flowcontrol.apiserver.k8s.io/v1"},
+	{"autoscaling", "v2beta2", "HorizontalPodAutoscaler", "v1.23.0", "v1.26.0", "autoscaling/v2"},
+
+	// v1.27
+	{"storage.k8s.io", "v1beta1", "CSIStorageCapacity", "v1.24.0", "v1.27.0", "storage.k8s.io/v1"},
+
+	// v1.29
+	{"flowcontrol.apiserver.k8s.io", "v1beta2", "FlowSchema", "v1.26.0", "v1.29.0", "flowcontrol.apiserver.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io", "v1beta2", "PriorityLevelConfiguration", "v1.26.0", "v1.29.0", "flowcontrol.apiserver.k8s.io/v1"},
+
+	// v1.32
+	{"flowcontrol.apiserver.k8s.io", "v1beta3", "FlowSchema", "v1.29.0", "v1.32.0", "flowcontrol.apiserver.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io", "v1beta3", "PriorityLevelConfiguration", "v1.29.0", "v1.32.0", "flowcontrol.apiserver.k8s.io/v1"},
+}
@@ -0,0 +1,204 @@
+// Package deprecations scans the offline render of catalog app versions (chart templates, CRDs and the
+// helmrelease kustomization objects) for apiVersions that are deprecated or removed in a target Kubernetes
+// version, and for custom resources served by CRD versions marked deprecated.
+package deprecations
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	catalogapptests "github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests"
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/chartcache"
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/render"
+)
+
+// Status is how an apiVersion fares on the target Kubernetes version.
+type Status string
+
+const (
+	// StatusRemoved means the apiVersion is no longer served: applying the object fails.
+	StatusRemoved Status = "removed"
+	// StatusDeprecated means the apiVersion still works but is scheduled for removal.
+	StatusDeprecated Status = "deprecated"
+)
+
+// Finding is one object using a deprecated or removed apiVersion.
+type Finding struct {
+	App         string
+	Version     string
+	KubeVersion string
+	APIVersion  string
+	Kind        string
+	Namespace   string
+	Name        string
+	Status      Status
+	// RemovedIn is the Kubernetes version removing the API (empty if unknown, e.g. deprecated CRD versions).
+	RemovedIn   string
+	Replacement string
+}
+
+func (f Finding) String() string {
+	obj := f.Kind + "/" + f.Name
+	if f.Namespace != "" {
+		obj = f.Kind + "/" + f.Namespace + "/" + f.Name
+	}
+	s := fmt.Sprintf("%s/%s on %s: %s %s is %s", f.App, f.Version, f.KubeVersion, f.APIVersion, obj, f.Status)
+	if f.Status == StatusDeprecated && f.RemovedIn != "" {
+		s += " (removed in " + f.RemovedIn + ")"
+	}
+	if f.Replacement != "" {
+		s += ", use " + f.Replacement
+	}
+	return s
+}
+
+// Scanner reports deprecated and removed APIs of catalog app versions for one Kubernetes version.
+type Scanner interface {
+	// KubeVersion returns the target Kubernetes version.
+	KubeVersion() string
+	// Scan renders applications/<app>/<version> (empty version = latest) for the target version and scans it.
+	Scan(ctx context.Context, appName, version string) ([]Finding, error)
+	// ScanObjects scans already rendered objects.
+	ScanObjects(appName, version string, objs []*unstructured.Unstructured) []Finding
+}
+
+// Ensure scanner implements Scanner at compile time.
+var _ Scanner = (*scanner)(nil)
+
+type scanner struct {
+	kubeVersion *semver.Version
+	renderer    render.Renderer
+	apis        []API
+}
+
+// Option configures a Scanner.
+type Option func(*scanner)
+
+// WithAPIs adds APIs to KnownAPIs (e.g. third-party APIs the catalog depends on).
+func WithAPIs(apis ...API) Option {
+	return func(s *scanner) { s.apis = append(s.apis, apis...) }
+}
+
+// NewScanner returns a Scanner for kubeVersion (e.g. "v1.33.1" or "1.33"). Charts are rendered from the
+// cache with .Capabilities.KubeVersion set to kubeVersion, so version-dependent templates pick the APIs
+// they would on that cluster.
+func NewScanner(cat catalogapptests.Catalog, charts chartcache.Cache, kubeVersion string, opts ...Option) (Scanner, error) {
+	v, err := semver.NewVersion(kubeVersion)
+	if err != nil {
+		return nil, fmt.Errorf("kubernetes version %q: %w", kubeVersion, err)
+	}
+	s := &scanner{kubeVersion: v, apis: append([]API(nil), KnownAPIs...)}
+	for _, o := range opts {
+		o(s)
+	}
+	if cat != nil {
+		s.renderer = render.NewRenderer(cat, charts, render.WithKubeVersion("v"+v.String()))
+	}
+	return s, nil
+}
+
+func (s *scanner) KubeVersion() string {
+	return "v" + s.kubeVersion.String()
+}
+
+func (s *scanner) Scan(ctx context.Context, appName, version string) ([]Finding, error) {
+	if s.renderer == nil {
+		return nil, fmt.Errorf("scanner has no catalog")
+	}
+	res, err := s.renderer.Render(ctx, appName, version)
+	if err != nil {
+		return nil, err
+	}
+	objs := append([]*unstructured.Unstructured(nil), res.Kustomization...)
+	for _, rel := range res.Releases {
+		objs = append(objs, rel.Objects...)
+	}
+	return s.ScanObjects(appName, res.Version, objs), nil
+}
+
+func (s *scanner) ScanObjects(appName, version string, objs []*unstructured.Unstructured) []Finding {
+	deprecatedCRDVersions := map[schema.GroupVersionKind]bool{}
+	var out []Finding
+	add := func(o *unstructured.Unstructured, status Status, removedIn, replacement string) {
+		out = append(out, Finding{
+			App: appName, Version: version, KubeVersion: s.KubeVersion(),
+			APIVersion: o.GetAPIVersion(), Kind: o.GetKind(), Namespace: o.GetNamespace(), Name: o.GetName(),
+			Status: status, RemovedIn: removedIn, Replacement: replacement,
+		})
+	}
+	for _, o := range objs {
+		gvk := o.GroupVersionKind()
+		for _, api := range s.apis {
+			if api.Group != gvk.Group || api.Version != gvk.Version || api.Kind != gvk.Kind {
+				continue
+			}
+			if status, ok := s.status(api); ok {
+				add(o, status, api.RemovedIn, api.Replacement)
+			}
+		}
+		if gvk.Group == "apiextensions.k8s.io" && gvk.Kind == "CustomResourceDefinition" {
+			for _, d := range deprecatedVersions(o) {
+				deprecatedCRDVersions[d.gvk] = true
+				out = append(out, Finding{
+					App: appName, Version: version, KubeVersion: s.KubeVersion(),
+					APIVersion: d.gvk.GroupVersion().String(), Kind: o.GetKind(), Name: o.GetName(),
+					Status: StatusDeprecated, Replacement: d.replacement,
+				})
+			}
+		}
+	}
+	for _, o := range objs {
+		if deprecatedCRDVersions[o.GroupVersionKind()] {
+			add(o, StatusDeprecated, "", "")
+		}
+	}
+	return out
+}
+
+func (s *scanner) status(api API) (Status, bool) {
+	if api.RemovedIn != "" && !s.kubeVersion.LessThan(semver.MustParse(api.RemovedIn)) {
+		return StatusRemoved, true
+	}
+	if api.DeprecatedIn != "" && !s.kubeVersion.LessThan(semver.MustParse(api.DeprecatedIn)) {
+		return StatusDeprecated, true
+	}
+	return "", false
+}
+
+type crdVersion struct {
+	gvk         schema.GroupVersionKind
+	replacement string
+}
+
+// deprecatedVersions returns the served CRD versions marked deprecated, with the storage version as replacement.
+func deprecatedVersions(crd *unstructured.Unstructured) []crdVersion {
+	group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+	kind, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "kind")
+	versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	storage := ""
+	var out []crdVersion
+	for _, v := range versions {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := m["name"].(string)
+		if isStorage, _ := m["storage"].(bool); isStorage {
+			storage = name
+		}
+		served, _ := m["served"].(bool)
+		if deprecated, _ := m["deprecated"].(bool); deprecated && served {
+			out = append(out, crdVersion{gvk: schema.GroupVersionKind{Group: group, Version: name, Kind: kind}})
+		}
+	}
+	for i := range out {
+		if storage != "" && storage != out[i].gvk.Version {
+			out[i].replacement = group + "/" + storage
+		}
+	}
+	return out
+}
@@ -0,0 +1,121 @@
+package deprecations
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/chart"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	catalogapptests "github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests"
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/chartcache"
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/internal/testutil"
+)
+
+func TestDeprecations(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Deprecations Suite")
+}
+
+func objects(manifests ...string) []*unstructured.Unstructured {
+	var out []*unstructured.Unstructured
+	for _, m := range manifests {
+		var obj map[string]interface{}
+		Expect(yaml.Unmarshal([]byte(m), &obj)).To(Succeed())
+		out = append(out, &unstructured.Unstructured{Object: obj})
+	}
+	return out
+}
+
+const cronJob = `apiVersion: batch/v1beta1
+kind: CronJob
+metadata:
+  name: cleanup
+  namespace: demo
+`
+
+const crd = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names: {kind: Widget}
+  versions:
+    - {name: v1alpha1, served: true, storage: false, deprecated: true}
+    - {name: v1, served: true, storage: true}
+`
+
+const widget = `apiVersion: example.com/v1alpha1
+kind: Widget
+metadata:
+  name: w
+  namespace: demo
+`
+
+var _ = Describe("Scanner", func() {
+	It("reports deprecated and removed built-in APIs for the target version", func() {
+		s, err := NewScanner(nil, nil, "1.24")
+		Expect(err).ToNot(HaveOccurred())
+		findings := s.ScanObjects("demo", "1.0.0", objects(cronJob))
+		Expect(findings).To(HaveLen(1))
+		Expect(findings[0].Status).To(Equal(StatusDeprecated))
+		Expect(findings[0].String()).To(Equal(
+			"demo/1.0.0 on v1.24.0: batch/v1beta1 CronJob/demo/cleanup is deprecated (removed in v1.25.0), use batch/v1"))
+
+		s, err = NewScanner(nil, nil, "v1.25.3")
+		Expect(err).ToNot(HaveOccurred())
+		findings = s.ScanObjects("demo", "1.0.0", objects(cronJob))
+		Expect(findings).To(HaveLen(1))
+		Expect(findings[0].Status).To(Equal(StatusRemoved))
+
+		s, err = NewScanner(nil, nil, "1.20")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s.ScanObjects("demo", "1.0.0", objects(cronJob))).To(BeEmpty())
+	})
+
+	It("reports deprecated CRD versions and custom resources using them", func() {
+		s, err := NewScanner(nil, nil, "1.33")
+		Expect(err).ToNot(HaveOccurred())
+		findings := s.ScanObjects("demo", "1.0.0", objects(crd, widget))
+		Expect(findings).To(HaveLen(2))
+		Expect(findings[0].APIVersion).To(Equal("example.com/v1alpha1"))
+		Expect(findings[0].Kind).To(Equal("CustomResourceDefinition"))
+		Expect(findings[0].Replacement).To(Equal("example.com/v1"))
+		Expect(findings[1].Kind).To(Equal("Widget"))
+		Expect(findings[1].Status).To(Equal(StatusDeprecated))
+	})
+
+	It("renders charts for the target Kubernetes version", func() {
+		appsDir := GinkgoT().TempDir()
+		cacheDir := GinkgoT().TempDir()
+		testutil.WriteApp(appsDir, "demo", "1.0.0", testutil.HelmRelease("demo", "demo", "1.0.0", ""), "")
+		c := &chart.Chart{
+			Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "demo", Version: "1.0.0"},
+			Templates: []*chart.File{{Name: "templates/cronjob.yaml", Data: []byte(`apiVersion: {{ if semverCompare ">=1.21-0" .Capabilities.KubeVersion.Version }}batch/v1{{ else }}batch/v1beta1{{ end }}
+kind: CronJob
+metadata:
+  name: cleanup
+`)}},
+		}
+		testutil.CacheChart(cacheDir, c)
+
+		cat, err := catalogapptests.NewCatalogAt(appsDir)
+		Expect(err).ToNot(HaveOccurred())
+		cache, err := chartcache.NewCache(cacheDir)
+		Expect(err).ToNot(HaveOccurred())
+
+		old, err := NewScanner(cat, cache, "1.20.0")
+		Expect(err).ToNot(HaveOccurred())
+		findings, err := old.Scan(context.Background(), "demo", "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(findings).To(BeEmpty())
+
+		current, err := NewScanner(cat, cache, "1.33.0")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(current.Scan(context.Background(), "demo", "")).To(BeEmpty())
+	})
+})
@@ -6,8 +6,14 @@ import (
 	"path/filepath"
 	"sort"
 	"sync"
+
+	"github.com/Masterminds/semver/v3"
 )
 
+// SnapshotsDir is the directory inside applications/<app>/<version>/ holding the golden files of the
+// version's rendered HelmReleases (see the snapshot package).
+const SnapshotsDir = "__snapshots__"
+
 // AppVersions holds an app name and its version directories (sorted, oldest first).
 type AppVersions struct {
 	Name     string   // e.g. "podinfo"
@@ -25,6 +31,14 @@ type Catalog interface {
 	PathToApp(appName, version string) (string, error)
 	// PrevVersionPath returns the path to the second-to-latest version (for upgrade tests).
 	PrevVersionPath(appName string) (string, error)
+	// ChangedApps returns the apps whose applications/<app> tree changed relative to the git ref baseRef,
+	// plus the apps that depend on them (see BaseRefEnv).
+	ChangedApps(baseRef string) ([]AppVersions, error)
+	// CatalogSource returns the app's .catalog-source.yaml (nil if the app has none).
+	CatalogSource(appName string) (*CatalogSource, error)
+	// ValidateCatalogSource checks that every version's helmrelease references the source declared in
+	// .catalog-source.yaml (no-op for apps without one).
+	ValidateCatalogSource(appName string) error
 }
 
 var _ Catalog = (*catalog)(nil)
@@ -43,6 +57,20 @@ func NewCatalog() (Catalog, error) {
 	return &catalog{basePath: base}, nil
 }
 
+// NewCatalogAt returns a Catalog rooted at the given applications/ directory (e.g. for tools run outside the repo).
+func NewCatalogAt(applicationsDir string) (Catalog, error) {
+	abs, err := filepath.Abs(applicationsDir)
+	if err != nil {
+		return nil, err
+	}
+	if st, err := os.Stat(abs); err != nil {
+		return nil, err
+	} else if !st.IsDir() {
+		return nil, fmt.Errorf("not a directory: %s", abs)
+	}
+	return &catalog{basePath: abs}, nil
+}
+
 func resolveApplicationsBase() (string, error) {
 	wd, err := os.Getwd()
 	if err != nil {
@@ -57,6 +85,36 @@ func resolveApplicationsBase() (string, error) {
 	return "", os.ErrNotExist
 }
 
+// SortVersions sorts version directory names oldest first: names that do not parse as semver (lexically),
+// then semver versions in semver order ("1.9.0" before "1.10.0").
+func SortVersions(versions []string) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		vi, errI := semver.NewVersion(versions[i])
+		vj, errJ := semver.NewVersion(versions[j])
+		switch {
+		case errI != nil && errJ != nil:
+			return versions[i] < versions[j]
+		case errI != nil || errJ != nil:
+			return errI != nil
+		case vi.Equal(vj):
+			return versions[i] < versions[j]
+		default:
+			return vi.LessThan(vj)
+		}
+	})
+}
+
+// LatestVersion returns the last of versions in SortVersions order: the highest semver, or the last
+// (lexically sorted) entry when none parse as semver.
+func LatestVersion(versions []string) string {
+	if len(versions) == 0 {
+		return ""
+	}
+	sorted := append([]string(nil), versions...)
+	SortVersions(sorted)
+	return sorted[len(sorted)-1]
+}
+
 func isVersionDir(path string) bool {
 	if st, err := os.Stat(filepath.Join(path, "helmrelease")); err == nil && st.IsDir() {
 		return true
@@ -96,7 +154,7 @@ func (c *catalog) Apps() ([]AppVersions, error) {
 		if len(versions) == 0 {
 			continue
 		}
-		sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+		SortVersions(versions)
 		result = append(result, AppVersions{Name: name, Versions: versions})
 	}
 	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
@@ -126,40 +184,42 @@ func (c *catalog) PathToApp(appName, version string) (string, error) {
 		}
 		return p, nil
 	}
-	matches, err := filepath.Glob(filepath.Join(dir, "*"))
+	versions, err := versionDirs(dir)
 	if err != nil {
 		return "", err
 	}
-	var versionDirs []string
-	for _, m := range matches {
-		if st, err := os.Stat(m); err == nil && st.IsDir() && isVersionDir(m) {
-			versionDirs = append(versionDirs, m)
-		}
-	}
-	sort.Strings(versionDirs)
-	if len(versionDirs) == 0 {
+	if len(versions) == 0 {
 		return "", fmt.Errorf("no application directory found for %s in %s", appName, dir)
 	}
-	return versionDirs[len(versionDirs)-1], nil
+	return filepath.Join(dir, versions[len(versions)-1]), nil
 }
 
 func (c *catalog) PrevVersionPath(appName string) (string, error) {
 	dir := filepath.Join(c.basePath, appName)
-	matches, err := filepath.Glob(filepath.Join(dir, "*"))
+	versions, err := versionDirs(dir)
 	if err != nil {
 		return "", err
 	}
-	var versionDirs []string
+	if len(versions) < 2 {
+		return "", fmt.Errorf("no old version found for application: %s", appName)
+	}
+	return filepath.Join(dir, versions[len(versions)-2]), nil
+}
+
+// versionDirs returns the names of the version directories in dir, in SortVersions order.
+func versionDirs(dir string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		return nil, err
+	}
+	var versions []string
 	for _, m := range matches {
 		if st, err := os.Stat(m); err == nil && st.IsDir() && isVersionDir(m) {
-			versionDirs = append(versionDirs, m)
+			versions = append(versions, filepath.Base(m))
 		}
 	}
-	sort.Strings(versionDirs)
-	if len(versionDirs) < 2 {
-		return "", fmt.Errorf("no old version found for application: %s", appName)
-	}
-	return versionDirs[len(versionDirs)-2], nil
+	SortVersions(versions)
+	return versions, nil
 }
 
 // default catalog for package-level helpers (lazy init)
@@ -0,0 +1,36 @@
+package catalogapptests
+
+import (
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/internal/testutil"
+)
+
+var _ = Describe("Version ordering", Label("unit"), func() {
+	It("sorts semver versions numerically after non-semver names", func() {
+		versions := []string{"1.10.0", "main", "1.9.0", "1.10.0-rc.1", "1.2.0"}
+		SortVersions(versions)
+		Expect(versions).To(Equal([]string{"main", "1.2.0", "1.9.0", "1.10.0-rc.1", "1.10.0"}))
+		Expect(LatestVersion([]string{"1.9.0", "1.10.0"})).To(Equal("1.10.0"))
+		Expect(LatestVersion([]string{"b", "a"})).To(Equal("b"))
+	})
+
+	It("orders Apps, PathToApp and PrevVersionPath the same way", func() {
+		appsDir := GinkgoT().TempDir()
+		for _, v := range []string{"1.10.0", "1.9.0", "1.8.1"} {
+			testutil.WriteFile(filepath.Join(appsDir, "demo", v, "metadata.yaml"), "displayName: Demo\n")
+		}
+		cat, err := NewCatalogAt(appsDir)
+		Expect(err).ToNot(HaveOccurred())
+
+		apps, err := cat.Apps()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(apps).To(HaveLen(1))
+		Expect(apps[0].Versions).To(Equal([]string{"1.8.1", "1.9.0", "1.10.0"}))
+		Expect(cat.PathToApp("demo", "")).To(Equal(filepath.Join(appsDir, "demo", "1.10.0")))
+		Expect(cat.PrevVersionPath("demo")).To(Equal(filepath.Join(appsDir, "demo", "1.9.0")))
+	})
+})
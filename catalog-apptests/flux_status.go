@@ -0,0 +1,249 @@
+package catalogapptests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/fluxcd/cli-utils/pkg/object"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	apimeta "github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlClient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/framework"
+)
+
+// HelmReleaseKustomizationSuffix is the suffix of the Flux Kustomization a catalog app version's top-level
+// helmrelease.yaml declares (${releaseName}-helmrelease).
+const HelmReleaseKustomizationSuffix = "-helmrelease"
+
+// SourceNotReadyError reports a Flux source (OCIRepository, HelmRepository, GitRepository, Bucket) whose
+// artifact is not available, typically a fetch failure (bad URL or tag, auth, unreachable registry).
+type SourceNotReadyError struct {
+	Kind      string
+	Namespace string
+	Name      string
+	// Reason and Message are from the source's Ready condition (empty when it has none yet).
+	Reason  string
+	Message string
+}
+
+func (e *SourceNotReadyError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("%s %s/%s has no Ready condition yet", e.Kind, e.Namespace, e.Name)
+	}
+	return fmt.Sprintf("%s %s/%s not ready: %s: %s", e.Kind, e.Namespace, e.Name, e.Reason, e.Message)
+}
+
+// conditionsObject is a Flux object exposing its status conditions.
+type conditionsObject interface {
+	ctrlClient.Object
+	GetConditions() []metav1.Condition
+}
+
+// newSource returns an empty source object of kind (source.toolkit.fluxcd.io/v1).
+func newSource(kind string) (conditionsObject, error) {
+	switch kind {
+	case sourcev1.OCIRepositoryKind:
+		return &sourcev1.OCIRepository{}, nil
+	case sourcev1.HelmRepositoryKind:
+		return &sourcev1.HelmRepository{}, nil
+	case sourcev1.GitRepositoryKind:
+		return &sourcev1.GitRepository{}, nil
+	case sourcev1.BucketKind:
+		return &sourcev1.Bucket{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported source kind %s", kind)
+	}
+}
+
+// SourceReady returns nil when the source is Ready for its current generation, else a *SourceNotReadyError
+// (or the Get error). HelmRepositories of type oci have no status and are always ready.
+func SourceReady(ctx context.Context, c ctrlClient.Client, kind, namespace, name string) error {
+	src, err := newSource(kind)
+	if err != nil {
+		return err
+	}
+	if err := c.Get(ctx, ctrlClient.ObjectKey{Namespace: namespace, Name: name}, src); err != nil {
+		return fmt.Errorf("%s %s/%s: %w", kind, namespace, name, err)
+	}
+	if hr, ok := src.(*sourcev1.HelmRepository); ok && hr.Spec.Type == sourcev1.HelmRepositoryTypeOCI {
+		return nil
+	}
+	ready := readyCondition(src.GetConditions())
+	if ready != nil && ready.Status == metav1.ConditionTrue && ready.ObservedGeneration >= src.GetGeneration() {
+		return nil
+	}
+	e := &SourceNotReadyError{Kind: kind, Namespace: namespace, Name: name}
+	if ready != nil {
+		e.Reason, e.Message = ready.Reason, ready.Message
+	}
+	return e
+}
+
+// KustomizationReady returns nil when the Kustomization is Ready and, if revision is set, has applied it
+// (lastAppliedRevision "<revision>@sha256:..." or exactly revision). Otherwise the error carries the Ready
+// reason and message, wrapping the source's *SourceNotReadyError when the source is failing.
+func KustomizationReady(ctx context.Context, c ctrlClient.Client, namespace, name, revision string) error {
+	ks := &kustomizev1.Kustomization{}
+	if err := c.Get(ctx, ctrlClient.ObjectKey{Namespace: namespace, Name: name}, ks); err != nil {
+		return fmt.Errorf("Kustomization %s/%s: %w", namespace, name, err)
+	}
+	ready := readyCondition(ks.Status.Conditions)
+	applied := revision == "" || ks.Status.LastAppliedRevision == revision ||
+		strings.HasPrefix(ks.Status.LastAppliedRevision, revision+"@")
+	if ready != nil && ready.Status == metav1.ConditionTrue && ready.ObservedGeneration >= ks.Generation && applied {
+		return nil
+	}
+
+	msg := "no Ready condition yet"
+	switch {
+	case ready != nil && ready.Status != metav1.ConditionTrue:
+		msg = fmt.Sprintf("%s: %s", ready.Reason, ready.Message)
+	case ready != nil && !applied:
+		msg = fmt.Sprintf("applied revision %q, waiting for %s", ks.Status.LastAppliedRevision, revision)
+	case ready != nil:
+		msg = "Ready condition is stale"
+	}
+	srcNamespace := ks.Spec.SourceRef.Namespace
+	if srcNamespace == "" {
+		srcNamespace = namespace
+	}
+	if err := SourceReady(ctx, c, ks.Spec.SourceRef.Kind, srcNamespace, ks.Spec.SourceRef.Name); err != nil {
+		return fmt.Errorf("Kustomization %s/%s not ready (%s): source %w", namespace, name, msg, err)
+	}
+	return fmt.Errorf("Kustomization %s/%s not ready: %s", namespace, name, msg)
+}
+
+// InventoryObject identifies an object expected in a Kustomization's status.inventory.
+// Empty Namespace matches any namespace.
+type InventoryObject struct {
+	Group     string
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+func (o InventoryObject) String() string {
+	gk := o.Kind
+	if o.Group != "" {
+		gk += "." + o.Group
+	}
+	if o.Namespace == "" {
+		return gk + " " + o.Name
+	}
+	return gk + " " + o.Namespace + "/" + o.Name
+}
+
+// KustomizationInventoryContains returns an error listing the objects missing from the Kustomization's
+// status.inventory.
+func KustomizationInventoryContains(ctx context.Context, c ctrlClient.Client, namespace, name string, want ...InventoryObject) error {
+	ks := &kustomizev1.Kustomization{}
+	if err := c.Get(ctx, ctrlClient.ObjectKey{Namespace: namespace, Name: name}, ks); err != nil {
+		return fmt.Errorf("Kustomization %s/%s: %w", namespace, name, err)
+	}
+	var have []object.ObjMetadata
+	if ks.Status.Inventory != nil {
+		for _, e := range ks.Status.Inventory.Entries {
+			id, err := object.ParseObjMetadata(e.ID)
+			if err != nil {
+				return fmt.Errorf("Kustomization %s/%s inventory: %w", namespace, name, err)
+			}
+			have = append(have, id)
+		}
+	}
+	var missing []string
+	for _, w := range want {
+		found := false
+		for _, h := range have {
+			if h.GroupKind.Group == w.Group && h.GroupKind.Kind == w.Kind && h.Name == w.Name &&
+				(w.Namespace == "" || h.Namespace == w.Namespace) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, w.String())
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("Kustomization %s/%s inventory is missing %s", namespace, name, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// CatalogAppStatus checks a catalog app version as delivered to cluster and returns the problems found:
+// the chart sources of its helmrelease/ kustomization must be Ready, and for InstallModeGitOps the app
+// Kustomization and ${releaseName}-helmrelease (when declared) must be Ready at that version, the one applying
+// helmrelease/ with its HelmReleases and ConfigMaps in the inventory. Use with Eventually.
+func CatalogAppStatus(cluster Cluster, app *CatalogApp, appPath string) error {
+	objs, err := framework.BuildKustomization(filepath.Join(appPath, "helmrelease"), map[string]string{
+		"releaseNamespace": DefaultNamespace,
+		"releaseName":      app.AppName,
+	})
+	if err != nil {
+		return err
+	}
+	ctx, c := cluster.Ctx(), cluster.Client()
+	var errs []error
+	var inventory []InventoryObject
+	for _, o := range objs {
+		namespace := o.GetNamespace()
+		if namespace == "" {
+			namespace = DefaultNamespace
+		}
+		switch kind := o.GetKind(); kind {
+		case sourcev1.OCIRepositoryKind, sourcev1.HelmRepositoryKind, sourcev1.GitRepositoryKind, sourcev1.BucketKind:
+			if err := SourceReady(ctx, c, kind, namespace, o.GetName()); err != nil {
+				errs = append(errs, err)
+			}
+		case "HelmRelease", "ConfigMap":
+			inventory = append(inventory, InventoryObject{
+				Group: o.GroupVersionKind().Group, Kind: kind, Namespace: o.GetNamespace(), Name: o.GetName(),
+			})
+		}
+	}
+	if app.mode() != InstallModeGitOps {
+		return errors.Join(errs...)
+	}
+
+	// The helmrelease/ objects are in the inventory of ${releaseName}-helmrelease when the top-level
+	// kustomization declares it, else directly in the app Kustomization's.
+	top, err := framework.BuildKustomization(appPath, map[string]string{
+		"releaseNamespace": DefaultNamespace,
+		"releaseName":      app.AppName,
+	})
+	if err != nil {
+		return err
+	}
+	kustomizations := []string{app.AppName}
+	for _, o := range top {
+		if o.GetKind() == kustomizev1.KustomizationKind && o.GetName() == app.AppName+HelmReleaseKustomizationSuffix {
+			kustomizations = append(kustomizations, o.GetName())
+		}
+	}
+	version := filepath.Base(appPath)
+	for _, name := range kustomizations {
+		if err := KustomizationReady(ctx, c, DefaultNamespace, name, version); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	owner := kustomizations[len(kustomizations)-1]
+	if err := KustomizationInventoryContains(ctx, c, DefaultNamespace, owner, inventory...); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func readyCondition(conds []metav1.Condition) *metav1.Condition {
+	for i := range conds {
+		if conds[i].Type == apimeta.ReadyCondition {
+			return &conds[i]
+		}
+	}
+	return nil
+}
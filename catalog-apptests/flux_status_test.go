@@ -0,0 +1,136 @@
+package catalogapptests
+
+import (
+	"context"
+	"errors"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	apimeta "github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlClient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/framework"
+)
+
+// readyConditions returns a Ready condition observed at generation.
+func readyConditions(status metav1.ConditionStatus, generation int64, reason, message string) []metav1.Condition {
+	return []metav1.Condition{{Type: apimeta.ReadyCondition, Status: status, ObservedGeneration: generation, Reason: reason, Message: message}}
+}
+
+var _ = Describe("Flux status", Label("unit"), func() {
+	ctx := context.Background()
+	meta := func(name string, generation int64) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Namespace: DefaultNamespace, Name: name, Generation: generation}
+	}
+	newClient := func(objs ...ctrlClient.Object) ctrlClient.Client {
+		return fake.NewClientBuilder().WithScheme(framework.NewScheme()).WithObjects(objs...).Build()
+	}
+	ociRepository := func(name string, generation int64, conds []metav1.Condition) *sourcev1.OCIRepository {
+		return &sourcev1.OCIRepository{ObjectMeta: meta(name, generation), Status: sourcev1.OCIRepositoryStatus{Conditions: conds}}
+	}
+	kustomization := func(name string, generation int64, conds []metav1.Condition, lastApplied string) *kustomizev1.Kustomization {
+		return &kustomizev1.Kustomization{
+			ObjectMeta: meta(name, generation),
+			Spec:       kustomizev1.KustomizationSpec{SourceRef: kustomizev1.CrossNamespaceSourceReference{Kind: sourcev1.OCIRepositoryKind, Name: "src"}},
+			Status:     kustomizev1.KustomizationStatus{Conditions: conds, LastAppliedRevision: lastApplied},
+		}
+	}
+
+	Describe("SourceReady", func() {
+		It("accepts a source Ready at its generation", func() {
+			c := newClient(ociRepository("src", 2, readyConditions(metav1.ConditionTrue, 2, "Succeeded", "")))
+			Expect(SourceReady(ctx, c, sourcev1.OCIRepositoryKind, DefaultNamespace, "src")).To(Succeed())
+		})
+
+		It("rejects a Ready condition for an older generation", func() {
+			c := newClient(ociRepository("src", 3, readyConditions(metav1.ConditionTrue, 2, "Succeeded", "stored artifact")))
+			var notReady *SourceNotReadyError
+			Expect(SourceReady(ctx, c, sourcev1.OCIRepositoryKind, DefaultNamespace, "src")).To(BeAssignableToTypeOf(notReady))
+		})
+
+		It("reports the Ready reason and message", func() {
+			c := newClient(ociRepository("src", 1, readyConditions(metav1.ConditionFalse, 1, "OCIArtifactPullFailed", "tag not found")))
+			err := SourceReady(ctx, c, sourcev1.OCIRepositoryKind, DefaultNamespace, "src")
+			var notReady *SourceNotReadyError
+			Expect(err).To(BeAssignableToTypeOf(notReady))
+			Expect(err).To(MatchError("OCIRepository default/src not ready: OCIArtifactPullFailed: tag not found"))
+		})
+
+		It("reports a source without a Ready condition", func() {
+			c := newClient(ociRepository("src", 1, nil))
+			Expect(SourceReady(ctx, c, sourcev1.OCIRepositoryKind, DefaultNamespace, "src")).To(MatchError("OCIRepository default/src has no Ready condition yet"))
+		})
+
+		It("treats OCI HelmRepositories as always ready", func() {
+			c := newClient(&sourcev1.HelmRepository{ObjectMeta: meta("charts", 1), Spec: sourcev1.HelmRepositorySpec{Type: sourcev1.HelmRepositoryTypeOCI, URL: "oci://example.com/charts"}})
+			Expect(SourceReady(ctx, c, sourcev1.HelmRepositoryKind, DefaultNamespace, "charts")).To(Succeed())
+		})
+
+		It("wraps Get errors and rejects unknown kinds", func() {
+			c := newClient()
+			Expect(SourceReady(ctx, c, sourcev1.OCIRepositoryKind, DefaultNamespace, "missing")).To(MatchError(apierrors.IsNotFound, "IsNotFound"))
+			Expect(SourceReady(ctx, c, "Widget", DefaultNamespace, "x")).To(MatchError("unsupported source kind Widget"))
+		})
+	})
+
+	Describe("KustomizationReady", func() {
+		source := ociRepository("src", 1, readyConditions(metav1.ConditionTrue, 1, "Succeeded", ""))
+
+		It("matches the applied revision exactly or by its @digest prefix", func() {
+			c := newClient(source, kustomization("app", 1, readyConditions(metav1.ConditionTrue, 1, "ReconciliationSucceeded", ""), "1.0.0@sha256:abc"))
+			Expect(KustomizationReady(ctx, c, DefaultNamespace, "app", "1.0.0")).To(Succeed())
+			Expect(KustomizationReady(ctx, c, DefaultNamespace, "app", "1.0.0@sha256:abc")).To(Succeed())
+			Expect(KustomizationReady(ctx, c, DefaultNamespace, "app", "")).To(Succeed())
+			Expect(KustomizationReady(ctx, c, DefaultNamespace, "app", "1.0")).To(MatchError(ContainSubstring(`applied revision "1.0.0@sha256:abc", waiting for 1.0`)))
+		})
+
+		It("rejects a Ready condition for an older generation", func() {
+			c := newClient(source, kustomization("app", 2, readyConditions(metav1.ConditionTrue, 1, "ReconciliationSucceeded", ""), "1.0.0@sha256:abc"))
+			Expect(KustomizationReady(ctx, c, DefaultNamespace, "app", "1.0.0")).To(MatchError("Kustomization default/app not ready: Ready condition is stale"))
+		})
+
+		It("wraps the source error when the source is failing", func() {
+			c := newClient(
+				ociRepository("src", 1, readyConditions(metav1.ConditionFalse, 1, "OCIArtifactPullFailed", "unauthorized")),
+				kustomization("app", 1, readyConditions(metav1.ConditionFalse, 1, "ArtifactFailed", "source not ready"), ""),
+			)
+			err := KustomizationReady(ctx, c, DefaultNamespace, "app", "")
+			var notReady *SourceNotReadyError
+			Expect(errors.As(err, &notReady)).To(BeTrue())
+			Expect(notReady.Reason).To(Equal("OCIArtifactPullFailed"))
+			Expect(err).To(MatchError(ContainSubstring("Kustomization default/app not ready (ArtifactFailed: source not ready): source OCIRepository")))
+		})
+	})
+
+	Describe("KustomizationInventoryContains", func() {
+		It("lists the missing inventory entries", func() {
+			ks := kustomization("app", 1, nil, "")
+			ks.Status.Inventory = &kustomizev1.ResourceInventory{Entries: []kustomizev1.ResourceRef{
+				{ID: "default_podinfo_helm.toolkit.fluxcd.io_HelmRelease", Version: "v2"},
+				{ID: "apps_podinfo-config-defaults__ConfigMap", Version: "v1"},
+			}}
+			c := newClient(ks)
+			helmRelease := InventoryObject{Group: "helm.toolkit.fluxcd.io", Kind: "HelmRelease", Namespace: DefaultNamespace, Name: "podinfo"}
+			anyNamespace := InventoryObject{Kind: "ConfigMap", Name: "podinfo-config-defaults"}
+			Expect(KustomizationInventoryContains(ctx, c, DefaultNamespace, "app", helmRelease, anyNamespace)).To(Succeed())
+
+			err := KustomizationInventoryContains(ctx, c, DefaultNamespace, "app",
+				helmRelease,
+				InventoryObject{Kind: "ConfigMap", Namespace: DefaultNamespace, Name: "podinfo-config-defaults"},
+				InventoryObject{Kind: "Secret", Name: "podinfo-auth"},
+			)
+			Expect(err).To(MatchError("Kustomization default/app inventory is missing ConfigMap default/podinfo-config-defaults, Secret podinfo-auth"))
+		})
+
+		It("reports a Kustomization without an inventory", func() {
+			c := newClient(kustomization("app", 1, nil, ""))
+			Expect(KustomizationInventoryContains(ctx, c, DefaultNamespace, "app", InventoryObject{Kind: "ConfigMap", Name: "x"})).
+				To(MatchError(ContainSubstring("missing ConfigMap x")))
+		})
+	})
+})
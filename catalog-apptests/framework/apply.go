@@ -0,0 +1,68 @@
+package framework
+
+import (
+	"context"
+	"time"
+
+	"github.com/fluxcd/cli-utils/pkg/kstatus/polling"
+	runclient "github.com/fluxcd/pkg/runtime/client"
+	"github.com/fluxcd/pkg/ssa"
+	ssautils "github.com/fluxcd/pkg/ssa/utils"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// applyStaged server-side applies objs as owner in two stages, like flux install: cluster definitions
+// (CRDs, namespaces, cluster roles) first, waiting up to a minute for them, then the rest, waiting up to
+// timeout until kstatus reports them ready.
+func applyStaged(ctx context.Context, kubeconfigPath string, objs []*unstructured.Unstructured, owner ssa.Owner, timeout time.Duration) error {
+	flags := genericclioptions.NewConfigFlags(true)
+	flags.KubeConfig = &kubeconfigPath
+	opts := &runclient.Options{}
+
+	cfg, err := KubeConfig(kubeconfigPath)
+	if err != nil {
+		return err
+	}
+	cfg.QPS = opts.QPS
+	cfg.Burst = opts.Burst
+	mapper, err := flags.ToRESTMapper()
+	if err != nil {
+		return err
+	}
+	kubeClient, err := client.New(cfg, client.Options{Mapper: mapper, Scheme: NewScheme()})
+	if err != nil {
+		return err
+	}
+	poller := polling.NewStatusPoller(kubeClient, mapper, polling.Options{})
+	manager := ssa.NewResourceManager(kubeClient, poller, owner)
+
+	var stageOne, stageTwo []*unstructured.Unstructured
+	for _, u := range objs {
+		if ssautils.IsClusterDefinition(u) {
+			stageOne = append(stageOne, u)
+		} else {
+			stageTwo = append(stageTwo, u)
+		}
+	}
+	if len(stageOne) > 0 {
+		cs, err := manager.ApplyAll(ctx, stageOne, ssa.DefaultApplyOptions())
+		if err != nil {
+			return err
+		}
+		if err := manager.WaitForSet(cs.ToObjMetadataSet(), ssa.WaitOptions{Interval: 2 * time.Second, Timeout: time.Minute}); err != nil {
+			return err
+		}
+	}
+	if len(stageTwo) > 0 {
+		cs, err := manager.ApplyAll(ctx, stageTwo, ssa.DefaultApplyOptions())
+		if err != nil {
+			return err
+		}
+		if err := manager.WaitForSet(cs.ToObjMetadataSet(), ssa.WaitOptions{Interval: 2 * time.Second, Timeout: timeout}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package framework
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/memory"
+	"oras.land/oras-go/v2/registry/remote"
+)
+
+// Media types of artifacts produced by flux push artifact (what OCIRepository extracts by default).
+const (
+	FluxArtifactConfigMediaType  = "application/vnd.cncf.flux.config.v1+json"
+	FluxArtifactContentMediaType = "application/vnd.cncf.flux.content.v1.tar+gzip"
+)
+
+// PushDirectoryArtifact pushes dir as a Flux OCI artifact (a single tar+gzip layer, like flux push artifact)
+// to ref (<host>/<repository>:<tag>) and returns the manifest digest. source is recorded as the
+// org.opencontainers.image.source annotation. plainHTTP is for local registries (see Registry).
+func PushDirectoryArtifact(ctx context.Context, dir, ref, source string, plainHTTP bool) (string, error) {
+	i := strings.LastIndex(ref, ":")
+	if i < 0 || strings.Contains(ref[i:], "/") {
+		return "", fmt.Errorf("artifact reference %s has no tag", ref)
+	}
+	repoRef, tag := ref[:i], ref[i+1:]
+
+	layer, err := tarGzipDir(dir)
+	if err != nil {
+		return "", err
+	}
+	store := memory.New()
+	layerDesc := content.NewDescriptorFromBytes(FluxArtifactContentMediaType, layer)
+	configDesc := content.NewDescriptorFromBytes(FluxArtifactConfigMediaType, []byte("{}"))
+	for _, b := range []struct {
+		desc ocispec.Descriptor
+		data []byte
+	}{{layerDesc, layer}, {configDesc, []byte("{}")}} {
+		if err := store.Push(ctx, b.desc, bytes.NewReader(b.data)); err != nil {
+			return "", err
+		}
+	}
+	manifestDesc, err := oras.PackManifest(ctx, store, oras.PackManifestVersion1_0, "", oras.PackManifestOptions{
+		Layers:           []ocispec.Descriptor{layerDesc},
+		ConfigDescriptor: &configDesc,
+		ManifestAnnotations: map[string]string{
+			ocispec.AnnotationCreated:  time.Now().UTC().Format(time.RFC3339),
+			ocispec.AnnotationSource:   source,
+			ocispec.AnnotationRevision: tag,
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("pack artifact %s: %w", ref, err)
+	}
+	if err := store.Tag(ctx, manifestDesc, tag); err != nil {
+		return "", err
+	}
+
+	repo, err := remote.NewRepository(repoRef)
+	if err != nil {
+		return "", fmt.Errorf("artifact repository %s: %w", repoRef, err)
+	}
+	repo.PlainHTTP = plainHTTP
+	if _, err := oras.Copy(ctx, store, tag, repo, tag, oras.DefaultCopyOptions); err != nil {
+		return "", fmt.Errorf("push artifact %s: %w", ref, err)
+	}
+	return manifestDesc.Digest.String(), nil
+}
+
+// tarGzipDir archives the regular files under dir with paths relative to dir.
+func tarGzipDir(dir string) ([]byte, error) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("archive %s: %w", dir, err)
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -6,33 +6,76 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	"github.com/fluxcd/cli-utils/pkg/kstatus/polling"
 	"github.com/fluxcd/flux2/v2/pkg/manifestgen"
 	"github.com/fluxcd/flux2/v2/pkg/manifestgen/install"
 	"github.com/fluxcd/flux2/v2/pkg/manifestgen/kustomization"
-	runclient "github.com/fluxcd/pkg/runtime/client"
 	"github.com/fluxcd/pkg/ssa"
 	"github.com/fluxcd/pkg/ssa/normalize"
 	ssautils "github.com/fluxcd/pkg/ssa/utils"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/klog/v2"
-	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/kustomize/api/konfig"
 )
 
 const fluxNamespace = "kommander-flux"
 
+// DefaultFluxComponents are the controllers installed when FluxOptions.Components is empty.
+var DefaultFluxComponents = []string{"source-controller", "kustomize-controller", "helm-controller"}
+
+// DefaultFluxVersion is the Flux release whose manifests are embedded (fluxmanifests/<version>.tar.gz) and
+// installed offline when no Version is set. Keep it in step with github.com/fluxcd/flux2/v2 in go.mod.
+const DefaultFluxVersion = "v2.7.3"
+
+// FluxOptions configures InstallFluxWithOptions. Zero values keep the defaults (latest Flux release,
+// or DefaultFluxVersion when offline; kommander-flux namespace, DefaultFluxComponents).
+type FluxOptions struct {
+	// Version is the Flux release (e.g. "v2.6.4"); empty = "latest", or DefaultFluxVersion when Offline.
+	Version string
+	// Namespace Flux is installed in; empty = kommander-flux.
+	Namespace string
+	// Components replaces DefaultFluxComponents.
+	Components []string
+	// ExtraComponents are installed in addition to Components (e.g. "notification-controller",
+	// "image-reflector-controller", "image-automation-controller").
+	ExtraComponents []string
+	// ControllerFlags adds args to a controller's manager container, keyed by component name
+	// (e.g. "helm-controller": {"--concurrent=10"}); a flag already set by the manifests is replaced.
+	ControllerFlags map[string][]string
+	// ManifestsDir is a local copy of a Flux release's manifests (manifests.tar.gz extracted), used instead
+	// of downloading them from GitHub; Version is then informational.
+	ManifestsDir string
+	// ManifestsCacheDir caches the manifests of pinned versions (see FluxManifestsDir); empty uses
+	// DefaultFluxManifestsCacheDir().
+	ManifestsCacheDir string
+	// Offline never downloads manifests (a pinned Version must be embedded or cached, or ManifestsDir set)
+	// and side-loads the controller images into KindClusterName without pulling them: they must be in the
+	// local Docker daemon or the image cache.
+	Offline bool
+	// SideLoadImages loads the controller images into KindClusterName from the local Docker daemon or
+	// image cache (see LoadImagesIntoKind) instead of letting nodes pull them.
+	SideLoadImages bool
+	// KindClusterName is the Kind cluster images are side-loaded into.
+	KindClusterName string
+}
+
 // InstallFlux installs Flux (source-controller, kustomize-controller, helm-controller) on the cluster.
 func InstallFlux(ctx context.Context, kubeconfigPath, namespace string) error {
+	return InstallFluxWithOptions(ctx, kubeconfigPath, FluxOptions{Namespace: namespace})
+}
+
+// InstallFluxWithOptions installs Flux on the cluster as configured by fo and waits for it to be ready.
+func InstallFluxWithOptions(ctx context.Context, kubeconfigPath string, fo FluxOptions) error {
 	log.SetLogger(klog.NewKlogr())
+	namespace := fo.Namespace
 	if namespace == "" {
 		namespace = fluxNamespace
 	}
@@ -51,25 +94,52 @@ func InstallFlux(ctx context.Context, kubeconfigPath, namespace string) error {
 
 	options := install.MakeDefaultOptions()
 	options.Namespace = namespace
-	options.Components = []string{"source-controller", "kustomize-controller", "helm-controller"}
-
-	manifest, err := install.Generate(options, "")
-	if err != nil {
-		return fmt.Errorf("flux manifest generate: %w", err)
+	switch {
+	case fo.Version != "":
+		options.Version = fo.Version
+	case fo.Offline:
+		options.Version = DefaultFluxVersion
+	}
+	options.Components = append([]string(nil), DefaultFluxComponents...)
+	if len(fo.Components) > 0 {
+		options.Components = append([]string(nil), fo.Components...)
+	}
+	for _, c := range fo.ExtraComponents {
+		if !slices.Contains(options.Components, c) {
+			options.Components = append(options.Components, c)
+		}
 	}
+
 	tmpDir, err := manifestgen.MkdirTempAbs("", namespace)
 	if err != nil {
 		return err
 	}
 	defer os.RemoveAll(tmpDir)
+
+	// Pinned versions come from ManifestsDir, the embedded set or the on-disk cache; "latest" is
+	// downloaded by install.Generate.
+	manifestsDir := fo.ManifestsDir
+	if manifestsDir == "" && (fo.Offline || options.Version != "latest") {
+		if manifestsDir, err = FluxManifestsDir(ctx, options.Version, fo.ManifestsCacheDir, fo.Offline); err != nil {
+			return err
+		}
+	}
+	manifestsBase := ""
+	if manifestsDir != "" {
+		// Generate writes into the manifests base; work on a copy.
+		manifestsBase = filepath.Join(tmpDir, "base")
+		if err := os.CopyFS(manifestsBase, os.DirFS(manifestsDir)); err != nil {
+			return fmt.Errorf("copy flux manifests %s: %w", manifestsDir, err)
+		}
+	}
+	manifest, err := install.Generate(options, manifestsBase)
+	if err != nil {
+		return fmt.Errorf("flux manifest generate: %w", err)
+	}
 	if _, err := manifest.WriteFile(tmpDir); err != nil {
 		return fmt.Errorf("flux manifest write: %w", err)
 	}
 
-	flags := genericclioptions.NewConfigFlags(true)
-	flags.KubeConfig = &kubeconfigPath
-	opts := &runclient.Options{}
-
 	manifestPath := filepath.Join(tmpDir, manifest.Path)
 	objs, err := readFluxObjects(tmpDir, manifestPath)
 	if err != nil {
@@ -78,56 +148,115 @@ func InstallFlux(ctx context.Context, kubeconfigPath, namespace string) error {
 	if len(objs) == 0 {
 		return fmt.Errorf("no objects at %s", manifestPath)
 	}
-	if err := normalize.UnstructuredList(objs); err != nil {
+	if err := setControllerFlags(objs, fo.ControllerFlags); err != nil {
 		return err
 	}
-
-	cfg, err := KubeConfig(kubeconfigPath)
-	if err != nil {
-		return err
-	}
-	cfg.QPS = opts.QPS
-	cfg.Burst = opts.Burst
-	mapper, err := flags.ToRESTMapper()
-	if err != nil {
-		return err
+	if fo.Offline || fo.SideLoadImages {
+		if fo.KindClusterName == "" {
+			return fmt.Errorf("flux image side-loading needs KindClusterName")
+		}
+		var imageOpts []ImageOption
+		if fo.Offline {
+			imageOpts = append(imageOpts, WithOfflineImages())
+		}
+		if err := LoadImagesIntoKind(ctx, fo.KindClusterName, ContainerImages(objs), "", imageOpts...); err != nil {
+			return fmt.Errorf("side-load flux images: %w", err)
+		}
 	}
-	kubeClient, err := client.New(cfg, client.Options{Mapper: mapper, Scheme: NewScheme()})
-	if err != nil {
+	if err := normalize.UnstructuredList(objs); err != nil {
 		return err
 	}
-	poller := polling.NewStatusPoller(kubeClient, mapper, polling.Options{})
-	manager := ssa.NewResourceManager(kubeClient, poller, ssa.Owner{Field: "flux", Group: "fluxcd.io"})
 
-	var stageOne, stageTwo []*unstructured.Unstructured
+	return applyStaged(ctx, kubeconfigPath, objs, ssa.Owner{Field: "flux", Group: "fluxcd.io"}, 5*time.Minute)
+}
+
+// ContainerImages returns the distinct container and init container images of the workloads in objs.
+func ContainerImages(objs []*unstructured.Unstructured) []string {
+	var out []string
 	for _, u := range objs {
-		if ssautils.IsClusterDefinition(u) {
-			stageOne = append(stageOne, u)
-		} else {
-			stageTwo = append(stageTwo, u)
+		var fields []string
+		switch u.GetKind() {
+		case "Pod":
+			fields = []string{"spec"}
+		case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job":
+			fields = []string{"spec", "template", "spec"}
+		case "CronJob":
+			fields = []string{"spec", "jobTemplate", "spec", "template", "spec"}
+		default:
+			continue
 		}
-	}
-	if len(stageOne) > 0 {
-		cs, err := manager.ApplyAll(ctx, stageOne, ssa.DefaultApplyOptions())
-		if err != nil {
-			return err
-		}
-		if err := manager.WaitForSet(cs.ToObjMetadataSet(), ssa.WaitOptions{Interval: 2 * time.Second, Timeout: time.Minute}); err != nil {
-			return err
+		for _, field := range []string{"initContainers", "containers"} {
+			containers, _, _ := unstructured.NestedSlice(u.Object, append(fields, field)...)
+			for _, c := range containers {
+				cm, ok := c.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if image, _ := cm["image"].(string); image != "" && !slices.Contains(out, image) {
+					out = append(out, image)
+				}
+			}
 		}
 	}
-	if len(stageTwo) > 0 {
-		cs, err := manager.ApplyAll(ctx, stageTwo, ssa.DefaultApplyOptions())
-		if err != nil {
-			return err
+	return out
+}
+
+// setControllerFlags adds flags to the manager container of the controller Deployments named in flags.
+func setControllerFlags(objs []*unstructured.Unstructured, flags map[string][]string) error {
+	for component, extra := range flags {
+		found := false
+		for _, u := range objs {
+			if u.GetKind() != "Deployment" || u.GetName() != component {
+				continue
+			}
+			found = true
+			containers, _, err := unstructured.NestedSlice(u.Object, "spec", "template", "spec", "containers")
+			if err != nil {
+				return fmt.Errorf("%s containers: %w", component, err)
+			}
+			for i, c := range containers {
+				cm, ok := c.(map[string]interface{})
+				if !ok || cm["name"] != "manager" {
+					continue
+				}
+				args, _, _ := unstructured.NestedStringSlice(cm, "args")
+				cm["args"] = toInterfaces(mergeFlags(args, extra))
+				containers[i] = cm
+			}
+			if err := unstructured.SetNestedSlice(u.Object, containers, "spec", "template", "spec", "containers"); err != nil {
+				return err
+			}
 		}
-		if err := manager.WaitForSet(cs.ToObjMetadataSet(), ssa.WaitOptions{Interval: 2 * time.Second, Timeout: 5 * time.Minute}); err != nil {
-			return err
+		if !found {
+			return fmt.Errorf("controller flags for %s: component not installed", component)
 		}
 	}
 	return nil
 }
 
+// mergeFlags appends extra to args, replacing args with the same --name.
+func mergeFlags(args, extra []string) []string {
+	name := func(flag string) string {
+		n, _, _ := strings.Cut(flag, "=")
+		return n
+	}
+	out := make([]string, 0, len(args)+len(extra))
+	for _, a := range args {
+		if !slices.ContainsFunc(extra, func(e string) bool { return name(e) == name(a) }) {
+			out = append(out, a)
+		}
+	}
+	return append(out, extra...)
+}
+
+func toInterfaces(in []string) []interface{} {
+	out := make([]interface{}, len(in))
+	for i, s := range in {
+		out[i] = s
+	}
+	return out
+}
+
 // readFluxObjects reads YAML or kustomization from manifestPath (root for kustomize build).
 func readFluxObjects(root, manifestPath string) ([]*unstructured.Unstructured, error) {
 	fi, err := os.Lstat(manifestPath)
@@ -0,0 +1,100 @@
+package framework
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fluxcd/pkg/tar"
+)
+
+// FluxManifestsCacheEnv overrides the directory extracted Flux release manifests are cached in
+// (default: <user cache dir>/catalog-apptests/flux).
+const FluxManifestsCacheEnv = "FLUX_MANIFESTS_CACHE"
+
+// fluxReleasesURL is where release manifests are downloaded from when not embedded or cached.
+const fluxReleasesURL = "https://github.com/fluxcd/flux2/releases/download"
+
+// Refresh the embedded manifests of DefaultFluxVersion (needs network access):
+//go:generate sh -c "curl -sSfL -o fluxmanifests/v2.7.3.tar.gz https://github.com/fluxcd/flux2/releases/download/v2.7.3/manifests.tar.gz"
+
+//go:embed fluxmanifests
+var embeddedFluxManifests embed.FS
+
+// DefaultFluxManifestsCacheDir returns $FLUX_MANIFESTS_CACHE or <user cache dir>/catalog-apptests/flux.
+func DefaultFluxManifestsCacheDir() (string, error) {
+	return cacheDir(FluxManifestsCacheEnv, "flux")
+}
+
+// FluxManifestsDir returns a directory holding the manifests of a pinned Flux release (e.g. "v2.6.4"):
+// <cacheDir>/<version> if already extracted, else extracted from the embedded fluxmanifests/<version>.tar.gz,
+// else downloaded from GitHub releases (unless offline). Empty cacheDir uses DefaultFluxManifestsCacheDir().
+func FluxManifestsDir(ctx context.Context, version, cacheDir string, offline bool) (string, error) {
+	if version == "" || version == "latest" {
+		return "", fmt.Errorf("flux manifests: a pinned version is required (got %q)", version)
+	}
+	if !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+	if cacheDir == "" {
+		var err error
+		if cacheDir, err = DefaultFluxManifestsCacheDir(); err != nil {
+			return "", err
+		}
+	}
+	dir := filepath.Join(cacheDir, version)
+	if _, err := os.Stat(filepath.Join(dir, "rbac.yaml")); err == nil {
+		return dir, nil
+	}
+
+	if b, err := embeddedFluxManifests.ReadFile("fluxmanifests/" + version + ".tar.gz"); err == nil {
+		return dir, extractFluxManifests(bytes.NewReader(b), dir)
+	}
+	if offline {
+		return "", fmt.Errorf("flux manifests %s: not embedded and not cached in %s (offline)", version, dir)
+	}
+
+	u := fmt.Sprintf("%s/%s/manifests.tar.gz", fluxReleasesURL, version)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("download %s: %w", u, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("download %s: %s", u, resp.Status)
+	}
+	return dir, extractFluxManifests(resp.Body, dir)
+}
+
+// extractFluxManifests untars a release manifests.tar.gz into dir atomically (temp dir + rename).
+func extractFluxManifests(r io.Reader, dir string) error {
+	if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), filepath.Base(dir)+".tmp-")
+	if err != nil {
+		return err
+	}
+	if err := tar.Untar(r, tmp, tar.WithMaxUntarSize(-1)); err != nil {
+		_ = os.RemoveAll(tmp)
+		return fmt.Errorf("extract flux manifests: %w", err)
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		_ = os.RemoveAll(tmp)
+		if _, statErr := os.Stat(filepath.Join(dir, "rbac.yaml")); statErr == nil {
+			return nil // extracted concurrently
+		}
+		return err
+	}
+	return nil
+}
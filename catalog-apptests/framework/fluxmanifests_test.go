@@ -0,0 +1,23 @@
+package framework
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Embedded Flux manifests", func() {
+	It("hold DefaultFluxVersion", func() {
+		_, err := fs.Stat(embeddedFluxManifests, "fluxmanifests/"+DefaultFluxVersion+".tar.gz")
+		Expect(err).ToNot(HaveOccurred(), "embed the Flux %s manifests with go generate ./framework/", DefaultFluxVersion)
+	})
+
+	It("extract DefaultFluxVersion offline", func() {
+		dir, err := FluxManifestsDir(context.Background(), DefaultFluxVersion, GinkgoT().TempDir(), true)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(filepath.Join(dir, "rbac.yaml")).To(BeAnExistingFile())
+	})
+})
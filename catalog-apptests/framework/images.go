@@ -0,0 +1,257 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/client"
+	"sigs.k8s.io/kind/pkg/cluster"
+	"sigs.k8s.io/kind/pkg/cluster/nodes"
+	"sigs.k8s.io/kind/pkg/cluster/nodeutils"
+	"sigs.k8s.io/kind/pkg/cmd"
+)
+
+// NodeImageCacheEnv overrides the directory Kind node image tarballs are cached in
+// (default: <user cache dir>/catalog-apptests/node-images).
+const NodeImageCacheEnv = "KIND_NODE_IMAGE_CACHE"
+
+// ImageCacheEnv overrides the directory container image tarballs side-loaded into Kind are cached in
+// (default: <user cache dir>/catalog-apptests/images).
+const ImageCacheEnv = "IMAGE_CACHE_DIR"
+
+// ErrImageNotAvailable is returned by EnsureImage with WithOfflineImages when an image is neither in the
+// local Docker daemon nor in the image cache.
+var ErrImageNotAvailable = errors.New("image not available offline")
+
+// ImageOption configures EnsureImage and LoadImagesIntoKind.
+type ImageOption func(*imageOptions)
+
+type imageOptions struct {
+	offline bool
+}
+
+// WithOfflineImages never pulls: images must already be in the local Docker daemon or the image cache.
+func WithOfflineImages() ImageOption {
+	return func(o *imageOptions) { o.offline = true }
+}
+
+// KindNodeImageRepository is the repository of upstream Kind node images.
+const KindNodeImageRepository = "kindest/node"
+
+// NodeImageForVersion returns the Kind node image for a Kubernetes version ("v1.33.1" or "1.33.1" →
+// "kindest/node:v1.33.1"). Values that already are image references are returned unchanged.
+func NodeImageForVersion(version string) string {
+	if strings.ContainsAny(version, ":/@") {
+		return version
+	}
+	if !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+	return KindNodeImageRepository + ":" + version
+}
+
+// DefaultNodeImageCacheDir returns $KIND_NODE_IMAGE_CACHE or <user cache dir>/catalog-apptests/node-images.
+func DefaultNodeImageCacheDir() (string, error) {
+	return cacheDir(NodeImageCacheEnv, "node-images")
+}
+
+// DefaultImageCacheDir returns $IMAGE_CACHE_DIR or <user cache dir>/catalog-apptests/images.
+func DefaultImageCacheDir() (string, error) {
+	return cacheDir(ImageCacheEnv, "images")
+}
+
+func cacheDir(env, name string) (string, error) {
+	if d := os.Getenv(env); d != "" {
+		return d, nil
+	}
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "catalog-apptests", name), nil
+}
+
+// EnsureNodeImage makes a Kind node image available to the local Docker daemon (see EnsureImage).
+// Empty cacheDir uses DefaultNodeImageCacheDir().
+func EnsureNodeImage(ctx context.Context, nodeImage, cacheDir string) error {
+	if cacheDir == "" {
+		var err error
+		if cacheDir, err = DefaultNodeImageCacheDir(); err != nil {
+			return err
+		}
+	}
+	return EnsureImage(ctx, nodeImage, cacheDir)
+}
+
+// EnsureImage makes an image available to the local Docker daemon: already present, loaded from
+// <cacheDir>/<image>.tar, or pulled and then saved to the cache so later runs (and CI caches) skip the pull.
+// With WithOfflineImages it returns ErrImageNotAvailable instead of pulling.
+// Empty cacheDir uses DefaultImageCacheDir().
+func EnsureImage(ctx context.Context, ref, cacheDir string, opts ...ImageOption) error {
+	var o imageOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return fmt.Errorf("docker client: %w", err)
+	}
+	defer cli.Close()
+
+	if _, _, err := cli.ImageInspectWithRaw(ctx, ref); err == nil {
+		return nil
+	}
+	if cacheDir == "" {
+		if cacheDir, err = DefaultImageCacheDir(); err != nil {
+			return err
+		}
+	}
+	tarball := filepath.Join(cacheDir, imageFileName(ref))
+	if f, err := os.Open(tarball); err == nil {
+		defer f.Close()
+		resp, err := cli.ImageLoad(ctx, f, true)
+		if err != nil {
+			return fmt.Errorf("load %s: %w", tarball, err)
+		}
+		defer resp.Body.Close()
+		_, err = io.Copy(io.Discard, resp.Body)
+		return err
+	}
+	if o.offline {
+		return fmt.Errorf("%s: not in the Docker daemon or %s: %w", ref, tarball, ErrImageNotAvailable)
+	}
+
+	rc, err := cli.ImagePull(ctx, ref, image.PullOptions{})
+	if err != nil {
+		return fmt.Errorf("pull %s: %w", ref, err)
+	}
+	_, err = io.Copy(io.Discard, rc)
+	_ = rc.Close()
+	if err != nil {
+		return fmt.Errorf("pull %s: %w", ref, err)
+	}
+	return saveImage(ctx, cli, ref, tarball)
+}
+
+// LoadImagesIntoKind side-loads images into every node of a Kind cluster (like kind load docker-image),
+// first making them available locally with EnsureImage (opts are passed on). Images already on a node are skipped.
+func LoadImagesIntoKind(ctx context.Context, clusterName string, images []string, cacheDir string, opts ...ImageOption) error {
+	kindNodes, err := listKindNodes(clusterName)
+	if err != nil {
+		return err
+	}
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return fmt.Errorf("docker client: %w", err)
+	}
+	defer cli.Close()
+
+	tmpDir, err := os.MkdirTemp("", "kind-images-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for _, ref := range images {
+		if err := EnsureImage(ctx, ref, cacheDir, opts...); err != nil {
+			return err
+		}
+		archive := ""
+		for _, node := range kindNodes {
+			if id, err := nodeutils.ImageID(node, ref); err == nil && id != "" {
+				continue
+			}
+			if archive == "" {
+				archive = filepath.Join(tmpDir, imageFileName(ref))
+				if err := saveImage(ctx, cli, ref, archive); err != nil {
+					return err
+				}
+			}
+			if err := loadArchive(node, archive); err != nil {
+				return fmt.Errorf("load %s into %s: %w", ref, node.String(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// LoadImageArchiveIntoKind loads a docker save or OCI image archive (all images in it) into every node of a
+// Kind cluster, like kind load image-archive.
+func LoadImageArchiveIntoKind(ctx context.Context, clusterName, path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("image archive: %w", err)
+	}
+	kindNodes, err := listKindNodes(clusterName)
+	if err != nil {
+		return err
+	}
+	for _, node := range kindNodes {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := loadArchive(node, path); err != nil {
+			return fmt.Errorf("load %s into %s: %w", path, node.String(), err)
+		}
+	}
+	return nil
+}
+
+func listKindNodes(clusterName string) ([]nodes.Node, error) {
+	provider := cluster.NewProvider(cluster.ProviderWithLogger(cmd.NewLogger()))
+	kindNodes, err := provider.ListInternalNodes(clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("list nodes of %s: %w", clusterName, err)
+	}
+	if len(kindNodes) == 0 {
+		return nil, fmt.Errorf("kind cluster %s has no nodes", clusterName)
+	}
+	return kindNodes, nil
+}
+
+func loadArchive(node nodes.Node, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return nodeutils.LoadImageArchive(node, f)
+}
+
+func saveImage(ctx context.Context, cli *client.Client, ref, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	rc, err := cli.ImageSave(ctx, []string{ref})
+	if err != nil {
+		return fmt.Errorf("save %s: %w", ref, err)
+	}
+	defer rc.Close()
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, rc); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+		return fmt.Errorf("save %s: %w", ref, err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// imageFileName maps an image reference to a file name (kindest/node:v1.33.1 → kindest_node_v1.33.1.tar).
+func imageFileName(ref string) string {
+	if i := strings.Index(ref, "@"); i >= 0 {
+		ref = ref[:i]
+	}
+	return strings.NewReplacer("/", "_", ":", "_").Replace(ref) + ".tar"
+}
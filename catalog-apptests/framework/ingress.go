@@ -0,0 +1,92 @@
+package framework
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/nat"
+	"sigs.k8s.io/kind/pkg/apis/config/v1alpha4"
+)
+
+// NodePorts of the ingress controller Service that WithIngressPorts publishes on the host.
+const (
+	IngressHTTPNodePort  = 30080
+	IngressHTTPSNodePort = 30443
+)
+
+// WithIngressPorts publishes IngressHTTPNodePort and IngressHTTPSNodePort of the control-plane node on free
+// host ports of 127.0.0.1 (see KindHostAddress), so an ingress controller exposed on those NodePorts is
+// reachable from the host on any platform.
+func WithIngressPorts() KindOption {
+	return func(o *kindOptions) { o.ingressPorts = true }
+}
+
+// ingressPortMappings are the control-plane port mappings of WithIngressPorts; host port 0 lets Kind pick a
+// free port.
+func ingressPortMappings() []v1alpha4.PortMapping {
+	var out []v1alpha4.PortMapping
+	for _, p := range []int32{IngressHTTPNodePort, IngressHTTPSNodePort} {
+		out = append(out, v1alpha4.PortMapping{ContainerPort: p, ListenAddress: "127.0.0.1", Protocol: v1alpha4.PortMappingProtocolTCP})
+	}
+	return out
+}
+
+// KindHostAddress returns the host address (127.0.0.1:port) a container port of the Kind cluster's
+// control-plane node is published on.
+func KindHostAddress(ctx context.Context, clusterName string, containerPort int) (string, error) {
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return "", fmt.Errorf("docker client: %w", err)
+	}
+	defer cli.Close()
+	node := clusterName + "-control-plane"
+	inspect, err := cli.ContainerInspect(ctx, node)
+	if err != nil {
+		return "", fmt.Errorf("inspect %s: %w", node, err)
+	}
+	bindings := inspect.NetworkSettings.Ports[nat.Port(strconv.Itoa(containerPort)+"/tcp")]
+	if len(bindings) == 0 {
+		return "", fmt.Errorf("%s: port %d not published (create the cluster with WithIngressPorts)", node, containerPort)
+	}
+	host := bindings[0].HostIP
+	if host == "" || host == "0.0.0.0" {
+		host = "127.0.0.1"
+	}
+	return net.JoinHostPort(host, bindings[0].HostPort), nil
+}
+
+// NewIngressClient returns an HTTP client that sends every request for port 80 to httpAddr and for port
+// 443 to httpsAddr, keeping the URL's hostname for Host and SNI, so tests can request
+// http://<ingress host>/ without DNS. Server certificates are not verified.
+func NewIngressClient(httpAddr, httpsAddr string) *http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			_, port, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			switch port {
+			case "80":
+				addr = httpAddr
+			case "443":
+				addr = httpsAddr
+			}
+			return dialer.DialContext(ctx, network, addr)
+		},
+		TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+		DisableKeepAlives: true,
+	}
+	return &http.Client{
+		Transport: transport,
+		Timeout:   30 * time.Second,
+		// Report redirects (e.g. to a login page) instead of following them off the cluster.
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+}
@@ -6,8 +6,10 @@ import (
 	"os"
 	"sync"
 
+	"sigs.k8s.io/kind/pkg/apis/config/v1alpha4"
 	"sigs.k8s.io/kind/pkg/cluster"
 	"sigs.k8s.io/kind/pkg/cmd"
+	"sigs.k8s.io/yaml"
 )
 
 // KindCluster is a Kind cluster (name, kubeconfig path, provider for delete).
@@ -23,6 +25,19 @@ func (k *KindCluster) Name() string { return k.name }
 // KubeconfigFilePath returns the kubeconfig path.
 func (k *KindCluster) KubeconfigFilePath() string { return k.kubeconfig }
 
+// PeerKubeconfig returns a kubeconfig for clients on the same Docker network (other Kind clusters' pods,
+// registry or tool containers): the server is https://<name>-control-plane:6443 instead of the host port.
+func (k *KindCluster) PeerKubeconfig() ([]byte, error) {
+	if k.provider == nil {
+		return nil, fmt.Errorf("kind cluster %s: no provider", k.name)
+	}
+	cfg, err := k.provider.KubeConfig(k.name, true)
+	if err != nil {
+		return nil, fmt.Errorf("kind cluster %s: internal kubeconfig: %w", k.name, err)
+	}
+	return []byte(cfg), nil
+}
+
 // Delete removes the cluster and the temp kubeconfig file.
 func (k *KindCluster) Delete(ctx context.Context) error {
 	if k.provider == nil {
@@ -47,9 +62,62 @@ nodes:
 
 var kindCreateMu sync.Mutex
 
+// KindOption configures a Kind cluster created by NewKindCluster / NewKindClusterInNetwork.
+type KindOption func(*kindOptions)
+
+type kindOptions struct {
+	nodeImage    string
+	mirrors      []Mirror
+	ipFamily     IPFamily
+	ingressPorts bool
+}
+
+// WithNodeImage sets the node image (e.g. NodeImageForVersion("v1.33.1")); default is Kind's built-in image.
+func WithNodeImage(image string) KindOption {
+	return func(o *kindOptions) { o.nodeImage = image }
+}
+
+// WithRegistryMirrors pulls the mirrored registries through their mirror endpoints (see RegistryMirrors),
+// falling back to the upstream when a mirror fails.
+func WithRegistryMirrors(mirrors ...Mirror) KindOption {
+	return func(o *kindOptions) { o.mirrors = append(o.mirrors, mirrors...) }
+}
+
+// WithIPFamily sets the cluster IP family; IPv6 and dual-stack need a network with an IPv6 subnet
+// (see NetworkConfig.IPFamily) and use Kind's default pod subnets.
+func WithIPFamily(family IPFamily) KindOption {
+	return func(o *kindOptions) { o.ipFamily = family }
+}
+
+// kindConfig returns defaultKindConfig with the options applied.
+func (o kindOptions) kindConfig() (*v1alpha4.Cluster, error) {
+	cfg := &v1alpha4.Cluster{}
+	if err := yaml.Unmarshal(defaultKindConfig, cfg); err != nil {
+		return nil, fmt.Errorf("kind config: %w", err)
+	}
+	switch o.ipFamily {
+	case "", IPFamilyIPv4:
+	case IPFamilyIPv6:
+		cfg.Networking.IPFamily = v1alpha4.IPv6Family
+		cfg.Networking.PodSubnet = ""
+	case IPFamilyDual:
+		cfg.Networking.IPFamily = v1alpha4.DualStackFamily
+		cfg.Networking.PodSubnet = ""
+	default:
+		return nil, fmt.Errorf("unknown IP family %q", o.ipFamily)
+	}
+	if o.ingressPorts {
+		cfg.Nodes[0].ExtraPortMappings = append(cfg.Nodes[0].ExtraPortMappings, ingressPortMappings()...)
+	}
+	if len(o.mirrors) > 0 {
+		cfg.ContainerdConfigPatches = append(cfg.ContainerdConfigPatches, containerdConfigPathPatch)
+	}
+	return cfg, nil
+}
+
 // NewKindClusterInNetwork creates a Kind cluster in the given Docker network.
 // It sets KIND_EXPERIMENTAL_DOCKER_NETWORK for the duration of create.
-func NewKindClusterInNetwork(ctx context.Context, clusterName, networkName string) (*KindCluster, error) {
+func NewKindClusterInNetwork(ctx context.Context, clusterName, networkName string, opts ...KindOption) (*KindCluster, error) {
 	kindCreateMu.Lock()
 	defer kindCreateMu.Unlock()
 
@@ -59,14 +127,22 @@ func NewKindClusterInNetwork(ctx context.Context, clusterName, networkName strin
 		return nil, fmt.Errorf("set KIND_EXPERIMENTAL_DOCKER_NETWORK: %w", err)
 	}
 
-	return NewKindCluster(ctx, clusterName)
+	return NewKindCluster(ctx, clusterName, opts...)
 }
 
 // NewKindCluster creates a Kind cluster (uses default network if not set via env).
-func NewKindCluster(ctx context.Context, name string) (*KindCluster, error) {
+func NewKindCluster(ctx context.Context, name string, opts ...KindOption) (*KindCluster, error) {
 	if name == "" {
 		name = "catalog-test"
 	}
+	var o kindOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	cfg, err := o.kindConfig()
+	if err != nil {
+		return nil, err
+	}
 	kubeconfigFile, err := os.CreateTemp("", "*-kubeconfig")
 	if err != nil {
 		return nil, err
@@ -75,18 +151,32 @@ func NewKindCluster(ctx context.Context, name string) (*KindCluster, error) {
 	_ = kubeconfigFile.Close()
 
 	provider := cluster.NewProvider(cluster.ProviderWithLogger(cmd.NewLogger()))
-	err = provider.Create(name,
+	createOpts := []cluster.CreateOption{
 		cluster.CreateWithKubeconfigPath(kubeconfigPath),
-		cluster.CreateWithRawConfig(defaultKindConfig),
-	)
+		cluster.CreateWithV1Alpha4Config(cfg),
+	}
+	if o.nodeImage != "" {
+		createOpts = append(createOpts, cluster.CreateWithNodeImage(o.nodeImage))
+	}
+	err = provider.Create(name, createOpts...)
 	if err != nil {
 		_ = os.Remove(kubeconfigPath)
 		return nil, err
 	}
+	k := &KindCluster{name: name, kubeconfig: kubeconfigPath, provider: provider}
 	if err := provider.ExportKubeConfig(name, "", false); err != nil {
-		_ = provider.Delete(name, kubeconfigPath)
-		_ = os.Remove(kubeconfigPath)
+		_ = k.Delete(ctx)
 		return nil, err
 	}
-	return &KindCluster{name: name, kubeconfig: kubeconfigPath, provider: provider}, nil
+	if len(o.mirrors) > 0 {
+		kindNodes, err := listKindNodes(name)
+		if err == nil {
+			err = configureMirrors(kindNodes, o.mirrors)
+		}
+		if err != nil {
+			_ = k.Delete(ctx)
+			return nil, fmt.Errorf("configure registry mirrors: %w", err)
+		}
+	}
+	return k, nil
 }
@@ -18,19 +18,35 @@ import (
 
 // ApplyKustomizations builds the kustomization at path (with substitutions) and applies to the cluster.
 func ApplyKustomizations(ctx context.Context, ctrl ctrlClient.Client, path string, substitutions map[string]string) error {
+	objs, err := BuildKustomization(path, substitutions)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objs {
+		if err := ctrl.Patch(ctx, obj, ctrlClient.Apply, ctrlClient.ForceOwnership, ctrlClient.FieldOwner("catalog-apptests")); err != nil {
+			return fmt.Errorf("apply resource: %w", err)
+		}
+	}
+	return nil
+}
+
+// BuildKustomization builds the kustomization at path, applies envsubst substitutions and returns the objects
+// without touching a cluster (offline render of catalog manifests).
+func BuildKustomization(path string, substitutions map[string]string) ([]*unstructured.Unstructured, error) {
 	if path == "" {
-		return fmt.Errorf("path is required")
+		return nil, fmt.Errorf("path is required")
 	}
 	k := newKustomizer(path, substitutions)
 	if err := k.build(); err != nil {
-		return err
+		return nil, err
 	}
 	out, err := k.output()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	buf := bytes.NewBuffer(out)
 	dec := yaml.NewYAMLOrJSONDecoder(buf, 1<<20)
+	var objs []*unstructured.Unstructured
 	for {
 		var obj unstructured.Unstructured
 		err := dec.Decode(&obj)
@@ -38,13 +54,11 @@ func ApplyKustomizations(ctx context.Context, ctrl ctrlClient.Client, path strin
 			break
 		}
 		if err != nil {
-			return fmt.Errorf("decode at %s: %w", path, err)
-		}
-		if err := ctrl.Patch(ctx, &obj, ctrlClient.Apply, ctrlClient.ForceOwnership, ctrlClient.FieldOwner("catalog-apptests")); err != nil {
-			return fmt.Errorf("apply resource: %w", err)
+			return nil, fmt.Errorf("decode at %s: %w", path, err)
 		}
+		objs = append(objs, &obj)
 	}
-	return nil
+	return objs, nil
 }
 
 type kustomizer struct {
@@ -0,0 +1,216 @@
+package framework
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fluxcd/pkg/ssa"
+	ssautils "github.com/fluxcd/pkg/ssa/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlClient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// MetalLBVersion is the MetalLB release InstallMetalLB installs when MetalLBOptions.Version is empty.
+const MetalLBVersion = "v0.14.9"
+
+// MetalLBNamespace is the namespace of the MetalLB native manifests.
+const MetalLBNamespace = "metallb-system"
+
+// MetalLBManifestsCacheEnv overrides the directory downloaded MetalLB manifests are cached in
+// (default: <user cache dir>/catalog-apptests/metallb).
+const MetalLBManifestsCacheEnv = "METALLB_MANIFESTS_CACHE"
+
+// metalLBManifestURL is the native (L2/BGP without FRR) manifest of a MetalLB release.
+const metalLBManifestURL = "https://raw.githubusercontent.com/metallb/metallb/%s/config/manifests/metallb-native.yaml"
+
+// excludeFromLBLabel is set by Kind on control-plane nodes; MetalLB speakers do not announce from such nodes.
+const excludeFromLBLabel = "node.kubernetes.io/exclude-from-external-load-balancers"
+
+// MetalLBOptions configures InstallMetalLB.
+type MetalLBOptions struct {
+	// Version is the MetalLB release (e.g. "v0.14.9"); empty = MetalLBVersion.
+	Version string
+	// ManifestsCacheDir caches the downloaded manifests; empty = DefaultMetalLBManifestsCacheDir().
+	ManifestsCacheDir string
+	// Addresses are the pool's address ranges ("first-last" or CIDR, see LoadBalancerAddressRange).
+	Addresses []string
+}
+
+// DefaultMetalLBManifestsCacheDir returns $METALLB_MANIFESTS_CACHE or <user cache dir>/catalog-apptests/metallb.
+func DefaultMetalLBManifestsCacheDir() (string, error) {
+	return cacheDir(MetalLBManifestsCacheEnv, "metallb")
+}
+
+// InstallMetalLB installs MetalLB in L2 mode and waits until it is ready, then creates the IPAddressPool and
+// L2Advertisement "catalog-apptests" for opts.Addresses. Kind's exclude-from-external-load-balancers label is
+// removed from the nodes so single-node clusters announce their Services.
+func InstallMetalLB(ctx context.Context, kubeconfigPath string, opts MetalLBOptions) error {
+	if len(opts.Addresses) == 0 {
+		return fmt.Errorf("metallb: no pool addresses")
+	}
+	manifest, err := metalLBManifest(ctx, opts.Version, opts.ManifestsCacheDir)
+	if err != nil {
+		return err
+	}
+	objs, err := ssautils.ReadObjects(bytes.NewReader(manifest))
+	if err != nil {
+		return fmt.Errorf("metallb manifest: %w", err)
+	}
+	if err := applyStaged(ctx, kubeconfigPath, objs, ssa.Owner{Field: "catalog-apptests", Group: "metallb.io"}, 5*time.Minute); err != nil {
+		return fmt.Errorf("install metallb: %w", err)
+	}
+
+	c, _, err := NewClient(kubeconfigPath)
+	if err != nil {
+		return err
+	}
+	var nodeList corev1.NodeList
+	if err := c.List(ctx, &nodeList); err != nil {
+		return fmt.Errorf("list nodes: %w", err)
+	}
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+		if _, ok := node.Labels[excludeFromLBLabel]; !ok {
+			continue
+		}
+		patch := []byte(`{"metadata":{"labels":{"` + excludeFromLBLabel + `":null}}}`)
+		if err := c.Patch(ctx, node, ctrlClient.RawPatch(types.MergePatchType, patch)); err != nil {
+			return fmt.Errorf("unlabel node %s: %w", node.Name, err)
+		}
+	}
+
+	addresses := make([]interface{}, 0, len(opts.Addresses))
+	for _, a := range opts.Addresses {
+		addresses = append(addresses, a)
+	}
+	pool := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "metallb.io/v1beta1",
+		"kind":       "IPAddressPool",
+		"metadata":   map[string]interface{}{"name": "catalog-apptests", "namespace": MetalLBNamespace},
+		"spec":       map[string]interface{}{"addresses": addresses},
+	}}
+	l2 := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "metallb.io/v1beta1",
+		"kind":       "L2Advertisement",
+		"metadata":   map[string]interface{}{"name": "catalog-apptests", "namespace": MetalLBNamespace},
+		"spec":       map[string]interface{}{"ipAddressPools": []interface{}{"catalog-apptests"}},
+	}}
+	// The validating webhook may not serve yet although the controller is ready; retry until it does.
+	for _, obj := range []*unstructured.Unstructured{pool, l2} {
+		err := Poll(ctx, 2*time.Second, 2*time.Minute, func(ctx context.Context) error {
+			return c.Patch(ctx, obj, ctrlClient.Apply, ctrlClient.ForceOwnership, ctrlClient.FieldOwner("catalog-apptests"))
+		})
+		if err != nil {
+			return fmt.Errorf("apply %s: %w", obj.GetKind(), err)
+		}
+	}
+	return nil
+}
+
+// metalLBManifest returns the native manifest of a MetalLB release, downloading it into the cache once.
+func metalLBManifest(ctx context.Context, version, cacheDir string) ([]byte, error) {
+	if version == "" {
+		version = MetalLBVersion
+	}
+	if !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+	if cacheDir == "" {
+		var err error
+		if cacheDir, err = DefaultMetalLBManifestsCacheDir(); err != nil {
+			return nil, err
+		}
+	}
+	path := filepath.Join(cacheDir, version, "metallb-native.yaml")
+	if b, err := os.ReadFile(path); err == nil {
+		return b, nil
+	}
+
+	u := fmt.Sprintf(metalLBManifestURL, version)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download %s: %w", u, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download %s: %s", u, resp.Status)
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("download %s: %w", u, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return nil, err
+	}
+	return b, os.Rename(tmp, path)
+}
+
+// LoadBalancerAddressRange returns the index-th block of size addresses counted from the top of subnet, as
+// "first-last" for a MetalLB IPAddressPool. The subnet's last address (IPv4 broadcast) is skipped; Docker
+// assigns container addresses from the bottom, so blocks at the top stay free of Kind nodes.
+func LoadBalancerAddressRange(subnet string, index, size int) (string, error) {
+	p, err := netip.ParsePrefix(subnet)
+	if err != nil {
+		return "", fmt.Errorf("subnet %q: %w", subnet, err)
+	}
+	if index < 0 || size <= 0 {
+		return "", fmt.Errorf("invalid address block %d of size %d", index, size)
+	}
+	p = p.Masked()
+	last := lastAddr(p)
+	// Keep the lower half of the subnet for containers.
+	if hostBits := p.Addr().BitLen() - p.Bits(); hostBits < 63 && int64((index+1)*size+1) > int64(1)<<(hostBits-1) {
+		return "", fmt.Errorf("subnet %s has no room for address block %d of size %d", subnet, index, size)
+	}
+	end := addrAdd(last, -int64(index*size+1))
+	start := addrAdd(end, -int64(size-1))
+	return start.String() + "-" + end.String(), nil
+}
+
+// lastAddr returns the highest address of p.
+func lastAddr(p netip.Prefix) netip.Addr {
+	b := p.Addr().AsSlice()
+	for i := p.Bits(); i < len(b)*8; i++ {
+		b[i/8] |= byte(1) << (7 - i%8)
+	}
+	addr, _ := netip.AddrFromSlice(b)
+	return addr
+}
+
+// addrAdd returns a shifted by delta (no overflow handling; callers stay within a subnet).
+func addrAdd(a netip.Addr, delta int64) netip.Addr {
+	b := a.AsSlice()
+	carry := delta
+	for i := len(b) - 1; i >= 0 && carry != 0; i-- {
+		v := int64(b[i]) + carry%256
+		carry /= 256
+		if v < 0 {
+			v += 256
+			carry--
+		} else if v > 255 {
+			v -= 256
+			carry++
+		}
+		b[i] = byte(v)
+	}
+	addr, _ := netip.AddrFromSlice(b)
+	return addr
+}
@@ -0,0 +1,53 @@
+package framework
+
+import (
+	"net/netip"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MetalLB address ranges", func() {
+	DescribeTable("LoadBalancerAddressRange",
+		func(subnet string, index, size int, want string) {
+			r, err := LoadBalancerAddressRange(subnet, index, size)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(r).To(Equal(want))
+		},
+		Entry("/24 first block below the broadcast address", "10.0.0.0/24", 0, 10, "10.0.0.245-10.0.0.254"),
+		Entry("/24 second block", "10.0.0.0/24", 1, 10, "10.0.0.235-10.0.0.244"),
+		Entry("/24 unmasked subnet", "10.0.0.17/24", 0, 10, "10.0.0.245-10.0.0.254"),
+		Entry("/24 block filling the upper half", "10.0.0.0/24", 0, 127, "10.0.0.128-10.0.0.254"),
+		Entry("/16 first block", "172.18.0.0/16", 0, 10, "172.18.255.245-172.18.255.254"),
+		Entry("/16 block borrowing across bytes", "172.18.0.0/16", 2, 100, "172.18.254.211-172.18.255.54"),
+		Entry("IPv6 /64 first block", "fd00:ca7a::/64", 0, 10, "fd00:ca7a::ffff:ffff:ffff:fff5-fd00:ca7a::ffff:ffff:ffff:fffe"),
+		Entry("IPv6 /64 block borrowing across groups", "fd00:ca7a::/64", 1, 65536,
+			"fd00:ca7a::ffff:ffff:fffd:ffff-fd00:ca7a::ffff:ffff:fffe:fffe"),
+	)
+
+	DescribeTable("LoadBalancerAddressRange rejects",
+		func(subnet string, index, size int, errSubstring string) {
+			_, err := LoadBalancerAddressRange(subnet, index, size)
+			Expect(err).To(MatchError(ContainSubstring(errSubstring)))
+		},
+		Entry("an invalid subnet", "10.0.0.0", 0, 10, `subnet "10.0.0.0"`),
+		Entry("a negative index", "10.0.0.0/24", -1, 10, "invalid address block -1 of size 10"),
+		Entry("an empty block", "10.0.0.0/24", 0, 0, "invalid address block 0 of size 0"),
+		Entry("a block reaching the lower half", "10.0.0.0/24", 0, 128, "no room for address block 0 of size 128"),
+		Entry("an index past the upper half", "10.0.0.0/24", 12, 10, "no room for address block 12 of size 10"),
+	)
+
+	DescribeTable("addrAdd",
+		func(addr string, delta int, want string) {
+			Expect(addrAdd(netip.MustParseAddr(addr), int64(delta)).String()).To(Equal(want))
+		},
+		Entry("zero", "10.0.0.1", 0, "10.0.0.1"),
+		Entry("within a byte", "10.0.0.1", 5, "10.0.0.6"),
+		Entry("carry across bytes", "10.0.0.255", 1, "10.0.1.0"),
+		Entry("borrow across bytes", "10.0.1.0", -1, "10.0.0.255"),
+		Entry("borrow across two bytes", "10.1.0.0", -1, "10.0.255.255"),
+		Entry("more than a byte", "10.0.2.0", -300, "10.0.0.212"),
+		Entry("IPv6 carry", "fd00::ffff", 1, "fd00::1:0"),
+		Entry("IPv6 borrow", "fd00::1:0", -1, "fd00::ffff"),
+	)
+})
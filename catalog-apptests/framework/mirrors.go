@@ -0,0 +1,105 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/kind/pkg/cluster/nodes"
+)
+
+// DefaultMirroredRegistries maps the upstream registries NewRegistryMirrors proxies to their endpoints.
+var DefaultMirroredRegistries = map[string]string{
+	"docker.io":       "https://registry-1.docker.io",
+	"ghcr.io":         "https://ghcr.io",
+	"quay.io":         "https://quay.io",
+	"registry.k8s.io": "https://registry.k8s.io",
+}
+
+// containerdCertsDir is where containerd looks up per-registry hosts.toml (config_path).
+const containerdCertsDir = "/etc/containerd/certs.d"
+
+// containerdConfigPathPatch makes containerd read registry hosts from containerdCertsDir.
+const containerdConfigPathPatch = `[plugins."io.containerd.grpc.v1.cri".registry]
+  config_path = "` + containerdCertsDir + `"
+`
+
+// Mirror is a registry host pulled through a mirror endpoint reachable from the Kind nodes.
+type Mirror struct {
+	// Host is the upstream registry host as used in image references (e.g. docker.io).
+	Host string
+	// Upstream is the upstream endpoint (e.g. https://registry-1.docker.io), used when the mirror fails.
+	Upstream string
+	// Endpoint is the mirror URL on the Docker network (e.g. http://catalog-apptests-mirror-docker-io:5000).
+	Endpoint string
+}
+
+// hostsTOML is the containerd hosts.toml of m.
+func (m Mirror) hostsTOML() string {
+	return fmt.Sprintf("server = %q\n\n[host.%q]\n  capabilities = [\"pull\", \"resolve\"]\n", m.Upstream, m.Endpoint)
+}
+
+// RegistryMirrors is a set of pull-through cache registries (one per upstream) on a Docker network. Pass it to
+// Kind clusters with WithRegistryMirrors; the cached layers live in Docker volumes and survive Delete.
+type RegistryMirrors struct {
+	network    string
+	mirrors    []Mirror
+	registries []*Registry
+}
+
+// Mirrors returns the mirror of each upstream, sorted by host.
+func (m *RegistryMirrors) Mirrors() []Mirror { return append([]Mirror(nil), m.mirrors...) }
+
+// Network returns the Docker network the mirrors run on; only nodes on it can reach their endpoints.
+func (m *RegistryMirrors) Network() string { return m.network }
+
+// Delete removes the mirror containers (not their volumes).
+func (m *RegistryMirrors) Delete(ctx context.Context) error {
+	var errs []error
+	for _, r := range m.registries {
+		errs = append(errs, r.Delete(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+// NewRegistryMirrors starts a pull-through cache for each upstream (host → endpoint, e.g.
+// DefaultMirroredRegistries) on networkName, named <prefix>-mirror-<host> with a volume of the same name.
+func NewRegistryMirrors(ctx context.Context, networkName, prefix string, upstreams map[string]string) (*RegistryMirrors, error) {
+	hosts := make([]string, 0, len(upstreams))
+	for h := range upstreams {
+		hosts = append(hosts, h)
+	}
+	sort.Strings(hosts)
+
+	m := &RegistryMirrors{network: networkName}
+	for _, host := range hosts {
+		name := prefix + "-mirror-" + strings.NewReplacer(".", "-", ":", "-").Replace(host)
+		r, err := NewRegistry(ctx, name, networkName, WithRegistryProxy(upstreams[host]), WithRegistryVolume(name))
+		if err != nil {
+			_ = m.Delete(ctx)
+			return nil, err
+		}
+		m.registries = append(m.registries, r)
+		m.mirrors = append(m.mirrors, Mirror{Host: host, Upstream: upstreams[host], Endpoint: "http://" + r.NetworkAddress()})
+	}
+	return m, nil
+}
+
+// configureMirrors writes a containerd hosts.toml per mirror into every node; containerd reads them per pull.
+func configureMirrors(kindNodes []nodes.Node, mirrors []Mirror) error {
+	for _, node := range kindNodes {
+		for _, m := range mirrors {
+			dir := containerdCertsDir + "/" + m.Host
+			if err := node.Command("mkdir", "-p", dir).Run(); err != nil {
+				return fmt.Errorf("%s: mkdir %s: %w", node.String(), dir, err)
+			}
+			cmd := node.Command("cp", "/dev/stdin", dir+"/hosts.toml").SetStdin(strings.NewReader(m.hostsTOML()))
+			if err := cmd.Run(); err != nil {
+				return fmt.Errorf("%s: write %s/hosts.toml: %w", node.String(), dir, err)
+			}
+		}
+	}
+	return nil
+}
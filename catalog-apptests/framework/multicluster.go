@@ -0,0 +1,131 @@
+package framework
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// ProbePodImage is the image of the pods RunHTTPGetPod starts.
+const ProbePodImage = "busybox:1.36"
+
+// NetworkServiceSuffix is appended to a Service's name for the NodePort Service ExposeServiceOnNetwork creates.
+const NetworkServiceSuffix = "-network"
+
+// ExposeServiceOnNetwork creates (or updates) a NodePort Service <name>-network that selects the same pods as
+// the Service namespace/name for its port, and returns the address other clusters on the same Docker
+// network reach it at: <node InternalIP>:<node port>.
+func ExposeServiceOnNetwork(ctx context.Context, kubeconfigPath, namespace, name string, port int) (string, error) {
+	_, clientset, err := NewClient(kubeconfigPath)
+	if err != nil {
+		return "", err
+	}
+	svc, err := clientset.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	var target *corev1.ServicePort
+	for i := range svc.Spec.Ports {
+		if int(svc.Spec.Ports[i].Port) == port {
+			target = &svc.Spec.Ports[i]
+		}
+	}
+	if target == nil {
+		return "", fmt.Errorf("service %s/%s has no port %d", namespace, name, port)
+	}
+	targetPort := target.TargetPort
+	if targetPort == (intstr.IntOrString{}) {
+		targetPort = intstr.FromInt32(target.Port)
+	}
+	exposed := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: name + NetworkServiceSuffix, Namespace: namespace},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeNodePort,
+			Selector: svc.Spec.Selector,
+			Ports:    []corev1.ServicePort{{Name: target.Name, Port: target.Port, TargetPort: targetPort, Protocol: target.Protocol}},
+		},
+	}
+	services := clientset.CoreV1().Services(namespace)
+	current, err := services.Get(ctx, exposed.Name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		current, err = services.Create(ctx, exposed, metav1.CreateOptions{})
+	case err == nil:
+		// Keep the allocated node port.
+		exposed.Spec.Ports[0].NodePort = current.Spec.Ports[0].NodePort
+		current.Spec.Selector, current.Spec.Ports = exposed.Spec.Selector, exposed.Spec.Ports
+		current, err = services.Update(ctx, current, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return "", fmt.Errorf("expose %s/%s: %w", namespace, name, err)
+	}
+
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return "", fmt.Errorf("list nodes: %w", err)
+	}
+	for _, node := range nodes.Items {
+		for _, a := range node.Status.Addresses {
+			if a.Type == corev1.NodeInternalIP {
+				return net.JoinHostPort(a.Address, strconv.Itoa(int(current.Spec.Ports[0].NodePort))), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("expose %s/%s: no node with an InternalIP", namespace, name)
+}
+
+// RunHTTPGetPod runs a ProbePodImage pod in namespace that fetches url once (wget), waits up to timeout for it
+// to finish and returns the response body. The pod is deleted afterwards; a failed fetch returns its output.
+func RunHTTPGetPod(ctx context.Context, kubeconfigPath, namespace, url string, timeout time.Duration) (string, error) {
+	_, clientset, err := NewClient(kubeconfigPath)
+	if err != nil {
+		return "", err
+	}
+	pods := clientset.CoreV1().Pods(namespace)
+	pod, err := pods.Create(ctx, &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{GenerateName: "catalog-probe-", Namespace: namespace},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			Containers: []corev1.Container{{
+				Name:    "probe",
+				Image:   ProbePodImage,
+				Command: []string{"wget", "-q", "-O", "-", "-T", "10", url},
+			}},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return "", fmt.Errorf("create probe pod: %w", err)
+	}
+	defer func() { _ = pods.Delete(context.WithoutCancel(ctx), pod.Name, metav1.DeleteOptions{}) }()
+
+	err = Poll(ctx, 2*time.Second, timeout, func(ctx context.Context) error {
+		p, err := pods.Get(ctx, pod.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		pod = p
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			return nil
+		}
+		return fmt.Errorf("probe pod %s/%s for %s still %s", namespace, pod.Name, url, pod.Status.Phase)
+	})
+	if err != nil {
+		return "", err
+	}
+	logs, err := pods.GetLogs(pod.Name, &corev1.PodLogOptions{}).DoRaw(ctx)
+	if err != nil {
+		return "", fmt.Errorf("probe pod %s/%s logs: %w", namespace, pod.Name, err)
+	}
+	if pod.Status.Phase == corev1.PodFailed {
+		return "", fmt.Errorf("GET %s from pod %s/%s failed: %s", url, namespace, pod.Name, strings.TrimSpace(string(logs)))
+	}
+	return string(logs), nil
+}
@@ -2,8 +2,12 @@ package framework
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/netip"
 	"os"
+	"slices"
+	"strings"
 	"sync"
 
 	"github.com/docker/docker/api/types"
@@ -12,10 +16,63 @@ import (
 	"github.com/docker/docker/client"
 )
 
+// ErrMisconfiguredNetwork is returned when an existing Docker network does not match the requested settings.
+var ErrMisconfiguredNetwork = errors.New("docker network is misconfigured")
+
 // Network holds Docker network identity (name and ID) for Kind clusters.
 type Network struct {
 	ID   string
 	Name string
+	// Subnets are the network's IPAM subnets (IPv4 and/or IPv6 CIDRs).
+	Subnets []string
+	// Created is true when EnsureNetwork created the network (rather than finding it); see Delete.
+	Created bool
+}
+
+// Delete removes the Docker network. Containers still attached make it fail.
+func (n *Network) Delete(ctx context.Context) error {
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return fmt.Errorf("docker client: %w", err)
+	}
+	defer cli.Close()
+	if err := cli.NetworkRemove(ctx, n.ID); err != nil && !client.IsErrNotFound(err) {
+		return fmt.Errorf("network remove %s: %w", n.Name, err)
+	}
+	return nil
+}
+
+// IPFamily is the IP family of a Docker network or Kind cluster.
+type IPFamily string
+
+const (
+	IPFamilyIPv4 IPFamily = "ipv4"
+	IPFamilyIPv6 IPFamily = "ipv6"
+	IPFamilyDual IPFamily = "dual"
+)
+
+// Pools EnsureNetwork allocates subnets from when NetworkConfig.AllocateSubnets is set, in blocks of
+// SubnetBitsIPv4 / SubnetBitsIPv6.
+const (
+	SubnetPoolIPv4 = "10.200.0.0/13"
+	SubnetBitsIPv4 = 20
+	SubnetPoolIPv6 = "fd00:ca7a::/48"
+	SubnetBitsIPv6 = 64
+)
+
+// NetworkConfig describes the Docker network EnsureNetwork finds or creates.
+type NetworkConfig struct {
+	// Name of the network; "" uses GetDockerNetworkName().
+	Name string
+	// Subnets to create the network with (IPv4 and/or IPv6 CIDRs); an existing network must have them.
+	Subnets []string
+	// AllocateSubnets picks subnets for IPFamily that overlap no existing Docker network (from SubnetPoolIPv4 /
+	// SubnetPoolIPv6) when Subnets is empty and the network is created.
+	AllocateSubnets bool
+	// IPFamily of the network ("" = IPv4); IPv6 and dual enable IPv6 on the network.
+	IPFamily IPFamily
+	// Internal networks have no external connectivity.
+	Internal bool
 }
 
 var dockerNetworkMu sync.Mutex
@@ -28,36 +85,233 @@ func GetDockerNetworkName() string {
 	return "kind"
 }
 
-// EnsureDockerNetworkExist ensures a Docker network exists. subnet and internal can be "" and false for default.
+// EnsureDockerNetworkExist ensures a Docker network exists. subnet and internal can be "" and false for default;
+// subnet may list IPv4 and IPv6 CIDRs separated by commas (dual-stack). See EnsureNetwork.
 func EnsureDockerNetworkExist(ctx context.Context, subnet string, internal bool) (*Network, error) {
+	cfg := NetworkConfig{Internal: internal}
+	for _, s := range strings.Split(subnet, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			cfg.Subnets = append(cfg.Subnets, s)
+		}
+	}
+	return EnsureNetwork(ctx, cfg)
+}
+
+// EnsureNetwork returns the network named cfg.Name, creating it when missing. An existing network is validated
+// against cfg (internal flag, IPv6, subnets) and ErrMisconfiguredNetwork is returned on mismatch.
+func EnsureNetwork(ctx context.Context, cfg NetworkConfig) (*Network, error) {
+	switch cfg.IPFamily {
+	case "", IPFamilyIPv4, IPFamilyIPv6, IPFamilyDual:
+	default:
+		return nil, fmt.Errorf("unknown IP family %q", cfg.IPFamily)
+	}
+	dockerNetworkMu.Lock()
+	defer dockerNetworkMu.Unlock()
+
 	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
 	if err != nil {
 		return nil, fmt.Errorf("docker client: %w", err)
 	}
 	defer cli.Close()
 
-	name := GetDockerNetworkName()
-	list, err := cli.NetworkList(ctx, types.NetworkListOptions{
-		Filters: filters.NewArgs(filters.Arg("name", name)),
-	})
+	name := cfg.Name
+	if name == "" {
+		name = GetDockerNetworkName()
+	}
+	subnets, err := parseSubnets(cfg.Subnets)
+	if err != nil {
+		return nil, err
+	}
+	family := cfg.IPFamily
+	if family == "" {
+		family = IPFamilyIPv4
+		if hasIPv6(subnets) {
+			family = IPFamilyIPv6
+			if hasIPv4(subnets) {
+				family = IPFamilyDual
+			}
+		}
+	}
+
+	// The name filter matches substrings; look for the exact name.
+	list, err := cli.NetworkList(ctx, types.NetworkListOptions{Filters: filters.NewArgs(filters.Arg("name", name))})
 	if err != nil {
 		return nil, fmt.Errorf("network list: %w", err)
 	}
-	if len(list) > 0 {
-		return &Network{ID: list[0].ID, Name: list[0].Name}, nil
+	for _, n := range list {
+		if n.Name == name {
+			return validateNetwork(n, cfg.Internal, family, subnets)
+		}
 	}
 
+	if len(subnets) == 0 && cfg.AllocateSubnets {
+		all, err := cli.NetworkList(ctx, types.NetworkListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("network list: %w", err)
+		}
+		if subnets, err = allocateSubnets(all, family); err != nil {
+			return nil, err
+		}
+	}
+	enableIPv6 := family != IPFamilyIPv4
 	opts := network.CreateOptions{
-		Driver:   "bridge",
-		Internal: internal,
+		Driver:     "bridge",
+		Internal:   cfg.Internal,
+		EnableIPv6: &enableIPv6,
 	}
-	if subnet != "" {
-		opts.IPAM = &network.IPAM{Config: []network.IPAMConfig{{Subnet: subnet}}}
+	if len(subnets) > 0 {
+		opts.IPAM = &network.IPAM{}
+		for _, s := range subnets {
+			opts.IPAM.Config = append(opts.IPAM.Config, network.IPAMConfig{Subnet: s.String()})
+		}
 	}
-
 	resp, err := cli.NetworkCreate(ctx, name, opts)
 	if err != nil {
 		return nil, fmt.Errorf("network create %s: %w", name, err)
 	}
-	return &Network{ID: resp.ID, Name: name}, nil
+	n := &Network{ID: resp.ID, Name: name, Created: true}
+	for _, s := range subnets {
+		n.Subnets = append(n.Subnets, s.String())
+	}
+	return n, nil
+}
+
+// InspectNetwork returns the existing Docker network named name with its subnets.
+func InspectNetwork(ctx context.Context, name string) (*Network, error) {
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, fmt.Errorf("docker client: %w", err)
+	}
+	defer cli.Close()
+	n, err := cli.NetworkInspect(ctx, name, network.InspectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("network inspect %s: %w", name, err)
+	}
+	out := &Network{ID: n.ID, Name: n.Name}
+	for _, c := range n.IPAM.Config {
+		if p, err := netip.ParsePrefix(c.Subnet); err == nil {
+			out.Subnets = append(out.Subnets, p.Masked().String())
+		}
+	}
+	return out, nil
+}
+
+// validateNetwork checks an existing network against the requested settings.
+func validateNetwork(n network.Summary, internal bool, family IPFamily, want []netip.Prefix) (*Network, error) {
+	out := &Network{ID: n.ID, Name: n.Name}
+	var have []netip.Prefix
+	for _, c := range n.IPAM.Config {
+		if p, err := netip.ParsePrefix(c.Subnet); err == nil {
+			have = append(have, p.Masked())
+			out.Subnets = append(out.Subnets, p.Masked().String())
+		}
+	}
+	if n.Internal != internal {
+		return nil, fmt.Errorf("%w: %s has internal=%t, want %t", ErrMisconfiguredNetwork, n.Name, n.Internal, internal)
+	}
+	if family != IPFamilyIPv4 && (!n.EnableIPv6 || !hasIPv6(have)) {
+		return nil, fmt.Errorf("%w: %s has no IPv6 subnet, want %s", ErrMisconfiguredNetwork, n.Name, family)
+	}
+	if family != IPFamilyIPv6 && len(have) > 0 && !hasIPv4(have) {
+		return nil, fmt.Errorf("%w: %s has no IPv4 subnet, want %s", ErrMisconfiguredNetwork, n.Name, family)
+	}
+	for _, w := range want {
+		if !slices.Contains(have, w) {
+			return nil, fmt.Errorf("%w: %s has subnets %v, want %s", ErrMisconfiguredNetwork, n.Name, out.Subnets, w)
+		}
+	}
+	return out, nil
+}
+
+// allocateSubnets picks the first block of each needed family's pool that overlaps no existing network subnet.
+func allocateSubnets(existing []network.Summary, family IPFamily) ([]netip.Prefix, error) {
+	var used []netip.Prefix
+	for _, n := range existing {
+		for _, c := range n.IPAM.Config {
+			if p, err := netip.ParsePrefix(c.Subnet); err == nil {
+				used = append(used, p)
+			}
+		}
+	}
+	var out []netip.Prefix
+	if family != IPFamilyIPv6 {
+		p, err := freeSubnet(SubnetPoolIPv4, SubnetBitsIPv4, used)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	if family != IPFamilyIPv4 {
+		p, err := freeSubnet(SubnetPoolIPv6, SubnetBitsIPv6, used)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// freeSubnet returns the first /bits block of pool that overlaps none of used.
+func freeSubnet(pool string, bits int, used []netip.Prefix) (netip.Prefix, error) {
+	poolPrefix := netip.MustParsePrefix(pool)
+	candidate := netip.PrefixFrom(poolPrefix.Addr(), bits)
+	for poolPrefix.Contains(candidate.Addr()) {
+		free := true
+		for _, u := range used {
+			if u.Overlaps(candidate) {
+				free = false
+				break
+			}
+		}
+		if free {
+			return candidate, nil
+		}
+		next, err := nextPrefix(candidate)
+		if err != nil {
+			break
+		}
+		candidate = next
+	}
+	return netip.Prefix{}, fmt.Errorf("no free /%d subnet left in %s", bits, pool)
+}
+
+// nextPrefix returns the block of the same size following p.
+func nextPrefix(p netip.Prefix) (netip.Prefix, error) {
+	b := p.Addr().AsSlice()
+	// Add 1 at the last bit of the prefix, carrying towards the most significant byte.
+	bit := p.Bits() - 1
+	for i := bit / 8; i >= 0; i-- {
+		inc := byte(1)
+		if i == bit/8 {
+			inc = byte(1) << (7 - bit%8)
+		}
+		sum := b[i] + inc
+		carry := sum < b[i]
+		b[i] = sum
+		if !carry {
+			addr, _ := netip.AddrFromSlice(b)
+			return netip.PrefixFrom(addr, p.Bits()), nil
+		}
+	}
+	return netip.Prefix{}, fmt.Errorf("%s is the last block", p)
+}
+
+func parseSubnets(subnets []string) ([]netip.Prefix, error) {
+	var out []netip.Prefix
+	for _, s := range subnets {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return nil, fmt.Errorf("subnet %q: %w", s, err)
+		}
+		out = append(out, p.Masked())
+	}
+	return out, nil
+}
+
+func hasIPv4(prefixes []netip.Prefix) bool {
+	return slices.ContainsFunc(prefixes, func(p netip.Prefix) bool { return p.Addr().Is4() })
+}
+
+func hasIPv6(prefixes []netip.Prefix) bool {
+	return slices.ContainsFunc(prefixes, func(p netip.Prefix) bool { return p.Addr().Is6() })
 }
@@ -0,0 +1,112 @@
+package framework
+
+import (
+	"context"
+	"net/netip"
+
+	"github.com/docker/docker/api/types/network"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// summary returns a Docker network with the given IPAM subnets.
+func summary(internal, ipv6 bool, subnets ...string) network.Summary {
+	n := network.Summary{ID: "id", Name: "net", Internal: internal, EnableIPv6: ipv6}
+	for _, s := range subnets {
+		n.IPAM.Config = append(n.IPAM.Config, network.IPAMConfig{Subnet: s})
+	}
+	return n
+}
+
+func prefixes(subnets ...string) []netip.Prefix {
+	var out []netip.Prefix
+	for _, s := range subnets {
+		out = append(out, netip.MustParsePrefix(s))
+	}
+	return out
+}
+
+var _ = Describe("Docker networks", func() {
+	DescribeTable("nextPrefix",
+		func(p, want string) {
+			next, err := nextPrefix(netip.MustParsePrefix(p))
+			if want == "" {
+				Expect(err).To(MatchError(ContainSubstring("is the last block")))
+				return
+			}
+			Expect(err).ToNot(HaveOccurred())
+			Expect(next.String()).To(Equal(want))
+		},
+		Entry("IPv4 within a byte", "10.200.0.0/20", "10.200.16.0/20"),
+		Entry("IPv4 carry across bytes", "10.200.240.0/20", "10.201.0.0/20"),
+		Entry("IPv4 carry across several bytes", "10.255.255.0/24", "11.0.0.0/24"),
+		Entry("IPv6 within a byte", "fd00:ca7a::/64", "fd00:ca7a:0:1::/64"),
+		Entry("IPv6 carry across bytes", "fd00:ca7a:0:ff::/64", "fd00:ca7a:0:100::/64"),
+		Entry("IPv6 carry across groups", "fd00:ca7a:0:ffff::/64", "fd00:ca7a:1::/64"),
+		Entry("last IPv4 block", "255.255.255.0/24", ""),
+	)
+
+	DescribeTable("freeSubnet",
+		func(pool string, bits int, used []netip.Prefix, want string) {
+			p, err := freeSubnet(pool, bits, used)
+			if want == "" {
+				Expect(err).To(MatchError(ContainSubstring("no free /%d subnet left in %s", bits, pool)))
+				return
+			}
+			Expect(err).ToNot(HaveOccurred())
+			Expect(p.String()).To(Equal(want))
+		},
+		Entry("first block", SubnetPoolIPv4, SubnetBitsIPv4, nil, "10.200.0.0/20"),
+		Entry("skips overlapping blocks", SubnetPoolIPv4, SubnetBitsIPv4,
+			prefixes("10.200.0.0/20", "10.200.20.0/24", "172.18.0.0/16"), "10.200.32.0/20"),
+		Entry("skips blocks inside a larger used subnet", SubnetPoolIPv4, SubnetBitsIPv4,
+			prefixes("10.200.0.0/16"), "10.201.0.0/20"),
+		Entry("pool exhausted", "10.200.0.0/23", 24, prefixes("10.200.0.0/24", "10.200.1.0/24"), ""),
+		Entry("pool covered by a used supernet", SubnetPoolIPv4, SubnetBitsIPv4, prefixes("10.0.0.0/8"), ""),
+		Entry("IPv6 first block", SubnetPoolIPv6, SubnetBitsIPv6, nil, "fd00:ca7a::/64"),
+		Entry("IPv6 skips a used block", SubnetPoolIPv6, SubnetBitsIPv6, prefixes("fd00:ca7a::/64"), "fd00:ca7a:0:1::/64"),
+	)
+
+	DescribeTable("allocateSubnets",
+		func(family IPFamily, want ...string) {
+			existing := []network.Summary{summary(false, true, "10.200.0.0/20", "fd00:ca7a::/64")}
+			got, err := allocateSubnets(existing, family)
+			Expect(err).ToNot(HaveOccurred())
+			var subnets []string
+			for _, p := range got {
+				subnets = append(subnets, p.String())
+			}
+			Expect(subnets).To(Equal(want))
+		},
+		Entry("IPv4", IPFamilyIPv4, "10.200.16.0/20"),
+		Entry("IPv6", IPFamilyIPv6, "fd00:ca7a:0:1::/64"),
+		Entry("dual", IPFamilyDual, "10.200.16.0/20", "fd00:ca7a:0:1::/64"),
+	)
+
+	DescribeTable("validateNetwork",
+		func(n network.Summary, internal bool, family IPFamily, want []netip.Prefix, errSubstring string) {
+			out, err := validateNetwork(n, internal, family, want)
+			if errSubstring != "" {
+				Expect(err).To(MatchError(ErrMisconfiguredNetwork))
+				Expect(err).To(MatchError(ContainSubstring(errSubstring)))
+				return
+			}
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out.Name).To(Equal("net"))
+		},
+		Entry("matching IPv4 network", summary(false, false, "10.200.0.1/20"), false, IPFamilyIPv4, prefixes("10.200.0.0/20"), ""),
+		Entry("matching dual-stack network", summary(true, true, "10.200.0.0/20", "fd00:ca7a::/64"), true, IPFamilyDual, nil, ""),
+		Entry("Docker-managed subnets without a request", summary(false, false), false, IPFamilyIPv4, nil, ""),
+		Entry("internal mismatch", summary(false, false, "10.200.0.0/20"), true, IPFamilyIPv4, nil, "internal=false, want true"),
+		Entry("IPv6 not enabled", summary(false, false, "10.200.0.0/20", "fd00:ca7a::/64"), false, IPFamilyIPv6, nil, "no IPv6 subnet, want ipv6"),
+		Entry("no IPv6 subnet", summary(false, true, "10.200.0.0/20"), false, IPFamilyDual, nil, "no IPv6 subnet, want dual"),
+		Entry("no IPv4 subnet", summary(false, true, "fd00:ca7a::/64"), false, IPFamilyDual, nil, "no IPv4 subnet, want dual"),
+		Entry("subnet mismatch", summary(false, false, "10.200.0.0/20"), false, IPFamilyIPv4, prefixes("10.200.16.0/20"),
+			"has subnets [10.200.0.0/20], want 10.200.16.0/20"),
+	)
+
+	It("rejects an unknown IP family before contacting Docker", func() {
+		_, err := EnsureNetwork(context.Background(), NetworkConfig{Name: "net", IPFamily: "ipv5"})
+		Expect(err).To(MatchError(`unknown IP family "ipv5"`))
+	})
+})
@@ -0,0 +1,27 @@
+package framework
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Poll calls check every interval until it returns nil, ctx is done (returning ctx.Err()) or timeout passes
+// (returning the last check error).
+func Poll(ctx context.Context, interval, timeout time.Duration, check func(ctx context.Context) error) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		err := check(ctx)
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("not done within %s: %w", timeout, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
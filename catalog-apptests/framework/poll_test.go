@@ -0,0 +1,42 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Poll", func() {
+	errNotYet := errors.New("not yet")
+
+	It("returns once check succeeds", func() {
+		calls := 0
+		err := Poll(context.Background(), time.Millisecond, time.Minute, func(context.Context) error {
+			calls++
+			if calls < 3 {
+				return errNotYet
+			}
+			return nil
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(calls).To(Equal(3))
+	})
+
+	It("returns the last check error after the timeout", func() {
+		err := Poll(context.Background(), time.Millisecond, 10*time.Millisecond, func(context.Context) error {
+			return errNotYet
+		})
+		Expect(err).To(MatchError(errNotYet))
+		Expect(err).To(MatchError(ContainSubstring("not done within 10ms")))
+	})
+
+	It("stops when the context is done", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := Poll(ctx, time.Minute, time.Hour, func(context.Context) error { return errNotYet })
+		Expect(err).To(MatchError(context.Canceled))
+	})
+})
@@ -0,0 +1,248 @@
+package framework
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// PortForward is a running port-forward from 127.0.0.1 to a pod port. Close stops it.
+type PortForward struct {
+	localPort uint16
+	stopCh    chan struct{}
+	done      chan struct{}
+	once      sync.Once
+}
+
+// LocalAddress returns 127.0.0.1:<local port>.
+func (f *PortForward) LocalAddress() string {
+	return "127.0.0.1:" + strconv.Itoa(int(f.localPort))
+}
+
+// URL returns http://<LocalAddress><path>.
+func (f *PortForward) URL(path string) string {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return "http://" + f.LocalAddress() + path
+}
+
+// Close stops the port-forward and waits until it has ended.
+func (f *PortForward) Close() {
+	f.once.Do(func() { close(f.stopCh) })
+	<-f.done
+}
+
+// PortForwardPod forwards a free local port to remotePort of the pod, using the SPDY forwarder with the
+// kubeconfig's credentials. The forward ends on Close or when ctx is done.
+func PortForwardPod(ctx context.Context, kubeconfigPath, namespace, pod string, remotePort int) (*PortForward, error) {
+	cfg, err := KubeConfig(kubeconfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("kubeconfig: %w", err)
+	}
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("clientset: %w", err)
+	}
+	transport, upgrader, err := spdy.RoundTripperFor(cfg)
+	if err != nil {
+		return nil, err
+	}
+	u := clientset.CoreV1().RESTClient().Post().Resource("pods").Namespace(namespace).Name(pod).SubResource("portforward").URL()
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, u)
+
+	f := &PortForward{stopCh: make(chan struct{}), done: make(chan struct{})}
+	readyCh := make(chan struct{})
+	var errOut bytes.Buffer
+	fw, err := portforward.NewOnAddresses(dialer, []string{"127.0.0.1"}, []string{fmt.Sprintf("0:%d", remotePort)},
+		f.stopCh, readyCh, io.Discard, &errOut)
+	if err != nil {
+		return nil, fmt.Errorf("port-forward %s/%s:%d: %w", namespace, pod, remotePort, err)
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		defer close(f.done)
+		errCh <- fw.ForwardPorts()
+	}()
+	go func() {
+		select {
+		case <-ctx.Done():
+			f.once.Do(func() { close(f.stopCh) })
+		case <-f.done:
+		}
+	}()
+
+	select {
+	case <-readyCh:
+	case err := <-errCh:
+		return nil, fmt.Errorf("port-forward %s/%s:%d: %w %s", namespace, pod, remotePort, err, strings.TrimSpace(errOut.String()))
+	case <-ctx.Done():
+		f.Close()
+		return nil, ctx.Err()
+	}
+	ports, err := fw.GetPorts()
+	if err != nil || len(ports) == 0 {
+		f.Close()
+		return nil, fmt.Errorf("port-forward %s/%s:%d: no local port: %v", namespace, pod, remotePort, err)
+	}
+	f.localPort = ports[0].Local
+	return f, nil
+}
+
+// PortForwardService forwards a free local port to the Service port of a ready pod behind the Service, like
+// kubectl port-forward svc/<name>: the pod is picked by the Service selector and the target port resolved
+// (numbers and container port names).
+func PortForwardService(ctx context.Context, kubeconfigPath, namespace, service string, port int) (*PortForward, error) {
+	_, clientset, err := NewClient(kubeconfigPath)
+	if err != nil {
+		return nil, err
+	}
+	svc, err := clientset.CoreV1().Services(namespace).Get(ctx, service, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var svcPort *corev1.ServicePort
+	for i := range svc.Spec.Ports {
+		if int(svc.Spec.Ports[i].Port) == port {
+			svcPort = &svc.Spec.Ports[i]
+		}
+	}
+	if svcPort == nil {
+		return nil, fmt.Errorf("service %s/%s has no port %d", namespace, service, port)
+	}
+	if len(svc.Spec.Selector) == 0 {
+		return nil, fmt.Errorf("service %s/%s has no selector", namespace, service)
+	}
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(svc.Spec.Selector).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if !podReady(pod) {
+			continue
+		}
+		target, err := podTargetPort(pod, svcPort.TargetPort, port)
+		if err != nil {
+			return nil, fmt.Errorf("service %s/%s: %w", namespace, service, err)
+		}
+		return PortForwardPod(ctx, kubeconfigPath, namespace, pod.Name, target)
+	}
+	return nil, fmt.Errorf("service %s/%s: no ready pod", namespace, service)
+}
+
+// podTargetPort resolves a Service targetPort on pod (empty targetPort = the Service port).
+func podTargetPort(pod *corev1.Pod, target intstr.IntOrString, servicePort int) (int, error) {
+	switch {
+	case target.Type == intstr.Int && target.IntVal == 0:
+		return servicePort, nil
+	case target.Type == intstr.Int:
+		return int(target.IntVal), nil
+	}
+	for _, c := range pod.Spec.Containers {
+		for _, p := range c.Ports {
+			if p.Name == target.StrVal {
+				return int(p.ContainerPort), nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("pod %s has no container port named %q", pod.Name, target.StrVal)
+}
+
+func podReady(pod *corev1.Pod) bool {
+	if pod.Status.Phase != corev1.PodRunning || pod.DeletionTimestamp != nil {
+		return false
+	}
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// HTTPProbe is a GET retried until it returns the expected response; see Run.
+type HTTPProbe struct {
+	// URL to request (e.g. PortForward.URL("/healthz")).
+	URL string
+	// Status expected; 0 = any 2xx.
+	Status int
+	// BodyContains, when set, must be a substring of the response body.
+	BodyContains string
+	// Attempts before giving up; 0 = 30.
+	Attempts int
+	// Interval between attempts; 0 = 2s.
+	Interval time.Duration
+	// Client used for requests; nil = a client with a 10s timeout.
+	Client *http.Client
+}
+
+// Run requests p.URL until the response matches or the attempts are used up, and returns the matching body
+// or the last error.
+func (p HTTPProbe) Run(ctx context.Context) ([]byte, error) {
+	attempts, interval, client := p.Attempts, p.Interval, p.Client
+	if attempts <= 0 {
+		attempts = 30
+	}
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	var lastErr error
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, fmt.Errorf("probe %s: %w (last error: %v)", p.URL, ctx.Err(), lastErr)
+			case <-time.After(interval):
+			}
+		}
+		body, err := p.once(ctx, client)
+		if err == nil {
+			return body, nil
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("probe %s failed after %d attempts: %w", p.URL, attempts, lastErr)
+}
+
+func (p HTTPProbe) once(ctx context.Context, client *http.Client) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if (p.Status == 0 && (resp.StatusCode < 200 || resp.StatusCode > 299)) || (p.Status != 0 && resp.StatusCode != p.Status) {
+		return nil, fmt.Errorf("status %s", resp.Status)
+	}
+	if p.BodyContains != "" && !bytes.Contains(body, []byte(p.BodyContains)) {
+		return nil, fmt.Errorf("body does not contain %q", p.BodyContains)
+	}
+	return body, nil
+}
@@ -0,0 +1,96 @@
+package framework
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+var _ = Describe("Port forwarding", func() {
+	Describe("HTTPProbe", func() {
+		var (
+			requests atomic.Int32
+			srv      *httptest.Server
+		)
+
+		// The server answers 503 to the first two requests, then 200 "ok ready" (or 204 on /empty).
+		BeforeEach(func() {
+			requests.Store(0)
+			srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if requests.Add(1) <= 2 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				if r.URL.Path == "/empty" {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				fmt.Fprint(w, "ok ready")
+			}))
+			DeferCleanup(srv.Close)
+		})
+
+		It("retries until a 2xx response", func() {
+			body, err := HTTPProbe{URL: srv.URL, Attempts: 5, Interval: time.Millisecond}.Run(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(body)).To(Equal("ok ready"))
+			Expect(requests.Load()).To(BeEquivalentTo(3))
+		})
+
+		It("matches the expected status and body", func() {
+			_, err := HTTPProbe{URL: srv.URL + "/empty", Status: http.StatusNoContent, Attempts: 5, Interval: time.Millisecond}.Run(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = HTTPProbe{URL: srv.URL, BodyContains: "ready", Attempts: 5, Interval: time.Millisecond}.Run(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns the last error once the attempts are used up", func() {
+			_, err := HTTPProbe{URL: srv.URL, Attempts: 2, Interval: time.Millisecond}.Run(context.Background())
+			Expect(err).To(MatchError(ContainSubstring("failed after 2 attempts: status 503 Service Unavailable")))
+
+			_, err = HTTPProbe{URL: srv.URL, BodyContains: "healthy", Attempts: 2, Interval: time.Millisecond}.Run(context.Background())
+			Expect(err).To(MatchError(ContainSubstring(`body does not contain "healthy"`)))
+		})
+
+		It("stops when the context is done", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			_, err := HTTPProbe{URL: srv.URL, Attempts: 5, Interval: time.Hour}.Run(ctx)
+			Expect(err).To(MatchError(context.Canceled))
+		})
+	})
+
+	DescribeTable("podTargetPort",
+		func(target intstr.IntOrString, want int, errSubstring string) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "app"},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{
+					{Name: "app", Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: 9898}}},
+					{Name: "sidecar", Ports: []corev1.ContainerPort{{Name: "metrics", ContainerPort: 9797}}},
+				}},
+			}
+			port, err := podTargetPort(pod, target, 80)
+			if errSubstring != "" {
+				Expect(err).To(MatchError(ContainSubstring(errSubstring)))
+				return
+			}
+			Expect(err).ToNot(HaveOccurred())
+			Expect(port).To(Equal(want))
+		},
+		Entry("unset targetPort uses the service port", intstr.FromInt32(0), 80, ""),
+		Entry("numeric targetPort", intstr.FromInt32(8080), 8080, ""),
+		Entry("named port of the first container", intstr.FromString("http"), 9898, ""),
+		Entry("named port of another container", intstr.FromString("metrics"), 9797, ""),
+		Entry("unknown named port", intstr.FromString("grpc"), 0, `pod app has no container port named "grpc"`),
+	)
+})
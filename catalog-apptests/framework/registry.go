@@ -0,0 +1,148 @@
+package framework
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/nat"
+)
+
+// RegistryImage is the OCI registry image run by NewRegistry.
+const RegistryImage = "registry:2"
+
+// registryPort is the port the registry listens on inside the Docker network.
+const registryPort = "5000"
+
+// Registry is a plain-HTTP OCI registry container on a Docker network: reachable from the host (push)
+// and from Kind nodes and pods on the same network (pull) by container name.
+type Registry struct {
+	name     string
+	hostAddr string
+}
+
+// Name returns the registry container name.
+func (r *Registry) Name() string { return r.name }
+
+// HostAddress returns host:port of the registry as published on the host (127.0.0.1).
+func (r *Registry) HostAddress() string { return r.hostAddr }
+
+// NetworkAddress returns host:port of the registry inside the Docker network.
+func (r *Registry) NetworkAddress() string { return r.name + ":" + registryPort }
+
+// Delete removes the registry container.
+func (r *Registry) Delete(ctx context.Context) error {
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return fmt.Errorf("docker client: %w", err)
+	}
+	defer cli.Close()
+	if err := cli.ContainerRemove(ctx, r.name, container.RemoveOptions{Force: true}); err != nil && !client.IsErrNotFound(err) {
+		return fmt.Errorf("remove registry %s: %w", r.name, err)
+	}
+	return nil
+}
+
+// RegistryOption configures a registry container started by NewRegistry.
+type RegistryOption func(*registryOptions)
+
+type registryOptions struct {
+	proxyURL string
+	volume   string
+}
+
+// WithRegistryProxy runs the registry as a pull-through cache of remoteURL (e.g. https://registry-1.docker.io).
+func WithRegistryProxy(remoteURL string) RegistryOption {
+	return func(o *registryOptions) { o.proxyURL = remoteURL }
+}
+
+// WithRegistryVolume stores the registry data in the named Docker volume, which outlives the container
+// (pulled layers are reused by later runs).
+func WithRegistryVolume(volume string) RegistryOption {
+	return func(o *registryOptions) { o.volume = volume }
+}
+
+// NewRegistry starts a registry container named name on networkName (replacing a leftover container of the
+// same name) and waits until it serves /v2/. The image is made available with EnsureImage.
+func NewRegistry(ctx context.Context, name, networkName string, opts ...RegistryOption) (*Registry, error) {
+	var o registryOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if err := EnsureImage(ctx, RegistryImage, ""); err != nil {
+		return nil, err
+	}
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, fmt.Errorf("docker client: %w", err)
+	}
+	defer cli.Close()
+
+	if err := cli.ContainerRemove(ctx, name, container.RemoveOptions{Force: true}); err != nil && !client.IsErrNotFound(err) {
+		return nil, fmt.Errorf("remove registry %s: %w", name, err)
+	}
+	port := nat.Port(registryPort + "/tcp")
+	cfg := &container.Config{Image: RegistryImage, ExposedPorts: nat.PortSet{port: {}}}
+	hostCfg := &container.HostConfig{PortBindings: nat.PortMap{port: {{HostIP: "127.0.0.1"}}}}
+	if o.proxyURL != "" {
+		cfg.Env = append(cfg.Env, "REGISTRY_PROXY_REMOTEURL="+o.proxyURL)
+	}
+	if o.volume != "" {
+		hostCfg.Mounts = []mount.Mount{{Type: mount.TypeVolume, Source: o.volume, Target: "/var/lib/registry"}}
+	}
+	resp, err := cli.ContainerCreate(ctx, cfg, hostCfg,
+		&network.NetworkingConfig{EndpointsConfig: map[string]*network.EndpointSettings{networkName: {}}},
+		nil, name)
+	if err != nil {
+		return nil, fmt.Errorf("create registry %s: %w", name, err)
+	}
+	r := &Registry{name: name}
+	if err := cli.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		_ = r.Delete(ctx)
+		return nil, fmt.Errorf("start registry %s: %w", name, err)
+	}
+	inspect, err := cli.ContainerInspect(ctx, resp.ID)
+	if err != nil {
+		_ = r.Delete(ctx)
+		return nil, fmt.Errorf("inspect registry %s: %w", name, err)
+	}
+	bindings := inspect.NetworkSettings.Ports[port]
+	if len(bindings) == 0 {
+		_ = r.Delete(ctx)
+		return nil, fmt.Errorf("registry %s: port %s not published", name, port)
+	}
+	r.hostAddr = "127.0.0.1:" + bindings[0].HostPort
+	if err := r.waitReady(ctx, time.Minute); err != nil {
+		_ = r.Delete(ctx)
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Registry) waitReady(ctx context.Context, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	u := "http://" + r.hostAddr + "/v2/"
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return err
+		}
+		if resp, err := http.DefaultClient.Do(req); err == nil {
+			_ = resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("registry %s not ready at %s: %w", r.name, u, ctx.Err())
+		case <-time.After(time.Second):
+		}
+	}
+}
@@ -6,11 +6,14 @@ import (
 	sourcev1b2 "github.com/fluxcd/source-controller/api/v1beta2"
 	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 )
 
-// NewScheme returns a runtime.Scheme with Flux CRDs registered (source, kustomize, helm).
+// NewScheme returns a runtime.Scheme with the built-in Kubernetes types and Flux CRDs registered
+// (source, kustomize, helm).
 func NewScheme() *runtime.Scheme {
 	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
 	_ = sourcev1b2.AddToScheme(scheme)
 	_ = sourcev1.AddToScheme(scheme)
 	_ = kustomizev1.AddToScheme(scheme)
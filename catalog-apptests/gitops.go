@@ -0,0 +1,155 @@
+package catalogapptests
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlClient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/framework"
+)
+
+// InstallModeEnv selects how catalog apps are installed when CatalogApp.Mode is not set ("direct" or "gitops").
+const InstallModeEnv = "CATALOG_INSTALL_MODE"
+
+// InstallMode is how a catalog app version reaches the cluster.
+type InstallMode string
+
+const (
+	// InstallModeDirect builds the app's helmrelease/ kustomization and applies it (default).
+	InstallModeDirect InstallMode = "direct"
+	// InstallModeGitOps pushes the app version directory as an OCI artifact to a registry on the cluster's
+	// network and lets Flux deliver it the way NKP does: an OCIRepository ${releaseName}-source and a
+	// Kustomization with postBuild substitution applying the top-level kustomization.yaml.
+	InstallModeGitOps InstallMode = "gitops"
+)
+
+// GitOpsArtifactRepository is the registry repository prefix app artifacts are pushed under (<prefix>/<app>:<version>).
+const GitOpsArtifactRepository = "catalog"
+
+// GitOpsTimeout bounds how long a GitOps install waits for the Kustomization to apply the pushed version.
+const GitOpsTimeout = 10 * time.Minute
+
+// DefaultInstallMode returns the mode from InstallModeEnv, or InstallModeDirect.
+func DefaultInstallMode() InstallMode {
+	if m := InstallMode(os.Getenv(InstallModeEnv)); m != "" {
+		return m
+	}
+	return InstallModeDirect
+}
+
+// installCatalogVersion installs the app version at appPath on cluster using the app's install mode.
+// Images of the version's inventory are preloaded first when enabled.
+func installCatalogVersion(cluster Cluster, app *CatalogApp, appPath string) error {
+	if app.preloadImages() {
+		images, err := AppImages(appPath)
+		if err != nil {
+			return err
+		}
+		if len(images) > 0 {
+			if err := cluster.PreloadImages(cluster.Ctx(), images...); err != nil {
+				return fmt.Errorf("preload images of %s: %w", app.AppName, err)
+			}
+		}
+	}
+	switch mode := app.mode(); mode {
+	case InstallModeDirect:
+		return cluster.ApplyKustomizations(cluster.Ctx(), filepath.Join(appPath, "helmrelease"), map[string]string{
+			"releaseNamespace": DefaultNamespace,
+			"releaseName":      app.AppName,
+		})
+	case InstallModeGitOps:
+		impl, ok := cluster.(*clusterImpl)
+		if !ok {
+			return fmt.Errorf("GitOps install requires cluster from KindCluster")
+		}
+		return impl.installGitOps(impl.ctx, app.AppName, appPath)
+	default:
+		return fmt.Errorf("unknown install mode %q (want %s or %s)", mode, InstallModeDirect, InstallModeGitOps)
+	}
+}
+
+// installGitOps pushes appPath to the cluster's registry, points ${releaseName}-source at it and waits until
+// the app Kustomization has applied that version (its wait covers the nested Kustomization and HelmRelease).
+func (c *clusterImpl) installGitOps(ctx context.Context, appName, appPath string) error {
+	reg, err := c.ociRegistry(ctx)
+	if err != nil {
+		return err
+	}
+	version := filepath.Base(appPath)
+	repository := GitOpsArtifactRepository + "/" + appName
+	if _, err := framework.PushDirectoryArtifact(ctx, appPath, reg.HostAddress()+"/"+repository+":"+version, "file://"+appPath, true); err != nil {
+		return err
+	}
+
+	source := &sourcev1.OCIRepository{
+		TypeMeta:   metav1.TypeMeta{APIVersion: sourcev1.GroupVersion.String(), Kind: sourcev1.OCIRepositoryKind},
+		ObjectMeta: metav1.ObjectMeta{Name: appName + "-source", Namespace: DefaultNamespace},
+		Spec: sourcev1.OCIRepositorySpec{
+			URL:       "oci://" + reg.NetworkAddress() + "/" + repository,
+			Reference: &sourcev1.OCIRepositoryRef{Tag: version},
+			Insecure:  true,
+			Interval:  metav1.Duration{Duration: time.Minute},
+		},
+	}
+	ks := &kustomizev1.Kustomization{
+		TypeMeta:   metav1.TypeMeta{APIVersion: kustomizev1.GroupVersion.String(), Kind: kustomizev1.KustomizationKind},
+		ObjectMeta: metav1.ObjectMeta{Name: appName, Namespace: DefaultNamespace},
+		Spec: kustomizev1.KustomizationSpec{
+			Interval:      metav1.Duration{Duration: 10 * time.Minute},
+			RetryInterval: &metav1.Duration{Duration: 30 * time.Second},
+			Timeout:       &metav1.Duration{Duration: GitOpsTimeout},
+			Path:          "./",
+			Prune:         true,
+			Wait:          true,
+			SourceRef:     kustomizev1.CrossNamespaceSourceReference{Kind: sourcev1.OCIRepositoryKind, Name: source.Name},
+			PostBuild: &kustomizev1.PostBuild{Substitute: map[string]string{
+				"releaseName":      appName,
+				"releaseNamespace": DefaultNamespace,
+			}},
+		},
+	}
+	for _, obj := range []ctrlClient.Object{source, ks} {
+		if err := c.client.Patch(ctx, obj, ctrlClient.Apply, ctrlClient.ForceOwnership, ctrlClient.FieldOwner("catalog-apptests")); err != nil {
+			return fmt.Errorf("apply %s %s: %w", obj.GetObjectKind().GroupVersionKind().Kind, obj.GetName(), err)
+		}
+	}
+	return c.waitKustomizationApplied(ctx, ks.Name, version, GitOpsTimeout)
+}
+
+// waitKustomizationApplied polls the Kustomization until it is Ready with revision applied (see
+// KustomizationReady); on timeout it returns the last KustomizationReady error.
+func (c *clusterImpl) waitKustomizationApplied(ctx context.Context, name, revision string, timeout time.Duration) error {
+	err := framework.Poll(ctx, PollInterval, timeout, func(ctx context.Context) error {
+		return KustomizationReady(ctx, c.client, DefaultNamespace, name, revision)
+	})
+	if err != nil {
+		return fmt.Errorf("%s not applied: %w", revision, err)
+	}
+	return nil
+}
+
+// ociRegistry returns the cluster's registry container, starting it on the cluster's network on first use.
+func (c *clusterImpl) ociRegistry(ctx context.Context) (*framework.Registry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.registry != nil {
+		return c.registry, nil
+	}
+	networkName := c.networkName
+	if networkName == "" {
+		networkName = framework.GetDockerNetworkName()
+	}
+	reg, err := framework.NewRegistry(ctx, c.name+"-registry", networkName)
+	if err != nil {
+		return nil, err
+	}
+	c.registry = reg
+	return reg, nil
+}
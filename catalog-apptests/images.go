@@ -0,0 +1,46 @@
+package catalogapptests
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ImagesFile is an app version's image inventory (applications/<app>/<version>/images.txt): one image
+// reference per line, blank lines and # comments ignored. Generate it with go run ./cmd/render --images.
+const ImagesFile = "images.txt"
+
+// PreloadImagesEnv, when "true", preloads each catalog app version's ImagesFile into the Kind nodes before
+// installing it (see CatalogApp.PreloadImages).
+const PreloadImagesEnv = "CATALOG_PRELOAD_IMAGES"
+
+// AppImages returns the image inventory of the app version at appPath (nil when it has no ImagesFile).
+func AppImages(appPath string) ([]string, error) {
+	f, err := os.Open(filepath.Join(appPath, ImagesFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var images []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line != "" {
+			images = append(images, line)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", f.Name(), err)
+	}
+	return images, nil
+}
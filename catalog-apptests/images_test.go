@@ -0,0 +1,48 @@
+package catalogapptests
+
+import (
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/internal/testutil"
+)
+
+var _ = Describe("AppImages", Label("unit"), func() {
+	var appPath string
+
+	BeforeEach(func() {
+		appPath = GinkgoT().TempDir()
+	})
+
+	It("skips comments and blank lines", func() {
+		testutil.WriteFile(filepath.Join(appPath, ImagesFile), `# Generated by go run ./cmd/render --images
+
+ghcr.io/stefanprodan/podinfo:6.9.4
+  busybox:1.36   # init container
+	# indented comment
+registry.k8s.io/pause@sha256:7031c1b283388d2c2e09b57badb803c05ebed362dc88d84b480cc47f72a21097
+`)
+		Expect(AppImages(appPath)).To(Equal([]string{
+			"ghcr.io/stefanprodan/podinfo:6.9.4",
+			"busybox:1.36",
+			"registry.k8s.io/pause@sha256:7031c1b283388d2c2e09b57badb803c05ebed362dc88d84b480cc47f72a21097",
+		}))
+	})
+
+	It("returns nil without an images.txt", func() {
+		Expect(AppImages(appPath)).To(BeNil())
+	})
+
+	It("returns an empty inventory for a file with only comments", func() {
+		testutil.WriteFile(filepath.Join(appPath, ImagesFile), "# no images\n\n")
+		Expect(AppImages(appPath)).To(BeEmpty())
+	})
+
+	It("reports unreadable inventories", func() {
+		testutil.WriteFile(filepath.Join(appPath, ImagesFile, "nested"), "")
+		_, err := AppImages(appPath)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,212 @@
+package catalogapptests
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/framework"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrlClient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// IngressEnv, when "true", makes the suite create clusters with ClusterConfig.Ingress, install the ingress
+// controller after Flux and check that every Ingress / HTTPRoute host serves after install and upgrade.
+const IngressEnv = "CATALOG_INGRESS"
+
+// IngressAppName is the catalog app InstallIngress installs.
+const IngressAppName = "traefik"
+
+// IngressTimeout bounds how long InstallIngress waits for the ingress controller Service.
+const IngressTimeout = 10 * time.Minute
+
+// ingressServiceSelector selects the traefik chart's Service.
+var ingressServiceSelector = ctrlClient.MatchingLabels{"app.kubernetes.io/name": IngressAppName}
+
+// ingressNodePorts maps the traefik entrypoints to the NodePorts published by ClusterConfig.Ingress.
+var ingressNodePorts = map[string]int32{
+	"web":       framework.IngressHTTPNodePort,
+	"websecure": framework.IngressHTTPSNodePort,
+}
+
+// ingressFieldOwner owns the HelmRelease values InstallIngress sets; distinct from the install's field owner
+// so re-applying the catalog version (upgrade) keeps them.
+const ingressFieldOwner = "catalog-apptests-ingress"
+
+// IngressEnabled reports whether IngressEnv is "true".
+func IngressEnabled() bool {
+	return os.Getenv(IngressEnv) == "true"
+}
+
+// InstallIngress installs IngressAppName from the cluster's catalog with HelmRelease values exposing the
+// web and websecure entrypoints on the NodePorts ClusterConfig.Ingress published, and waits for the Service.
+func (c *clusterImpl) InstallIngress() error {
+	if !c.ingress {
+		return fmt.Errorf("cluster %s: InstallIngress requires ClusterConfig.Ingress", c.name)
+	}
+	if err := c.Install(NewCatalogApp(IngressAppName, "")); err != nil {
+		return err
+	}
+	if err := c.client.Patch(c.ctx, ingressHelmRelease(), ctrlClient.Apply,
+		ctrlClient.ForceOwnership, ctrlClient.FieldOwner(ingressFieldOwner)); err != nil {
+		return fmt.Errorf("set %s NodePort values: %w", IngressAppName, err)
+	}
+	if err := framework.Poll(c.ctx, PollInterval, IngressTimeout, c.ingressNodePortsReady); err != nil {
+		return fmt.Errorf("ingress controller not exposed: %w", err)
+	}
+	return nil
+}
+
+// ingressHelmRelease is the apply configuration of the IngressAppName HelmRelease's spec.values: a NodePort
+// Service with ingressNodePorts (traefik chart values service.type and ports.<entrypoint>.nodePort).
+func ingressHelmRelease() *unstructured.Unstructured {
+	ports := map[string]interface{}{}
+	for name, nodePort := range ingressNodePorts {
+		ports[name] = map[string]interface{}{"nodePort": int64(nodePort)}
+	}
+	hr := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"values": map[string]interface{}{
+				"service": map[string]interface{}{"type": string(corev1.ServiceTypeNodePort)},
+				"ports":   ports,
+			},
+		},
+	}}
+	hr.SetAPIVersion("helm.toolkit.fluxcd.io/v2")
+	hr.SetKind("HelmRelease")
+	hr.SetNamespace(DefaultNamespace)
+	hr.SetName(IngressAppName)
+	return hr
+}
+
+// ingressNodePortsReady checks that the ingress controller Service exposes web and websecure on
+// ingressNodePorts (once helm-controller has applied the values).
+func (c *clusterImpl) ingressNodePortsReady(ctx context.Context) error {
+	var services corev1.ServiceList
+	if err := c.client.List(ctx, &services, ingressServiceSelector); err != nil {
+		return err
+	}
+	if len(services.Items) == 0 {
+		return fmt.Errorf("no %s Service yet", IngressAppName)
+	}
+	svc := &services.Items[0]
+	pinned := 0
+	for _, p := range svc.Spec.Ports {
+		if nodePort, ok := ingressNodePorts[p.Name]; ok && p.NodePort == nodePort {
+			pinned++
+		}
+	}
+	if pinned != len(ingressNodePorts) || svc.Spec.Type == corev1.ServiceTypeClusterIP {
+		return fmt.Errorf("service %s/%s does not expose web and websecure on NodePorts %d and %d yet",
+			svc.Namespace, svc.Name, framework.IngressHTTPNodePort, framework.IngressHTTPSNodePort)
+	}
+	return nil
+}
+
+func (c *clusterImpl) IngressClient(ctx context.Context) (*http.Client, error) {
+	if !c.ingress {
+		return nil, fmt.Errorf("cluster %s: IngressClient requires ClusterConfig.Ingress", c.name)
+	}
+	name, err := c.kindClusterName()
+	if err != nil {
+		return nil, err
+	}
+	httpAddr, err := framework.KindHostAddress(ctx, name, framework.IngressHTTPNodePort)
+	if err != nil {
+		return nil, err
+	}
+	httpsAddr, err := framework.KindHostAddress(ctx, name, framework.IngressHTTPSNodePort)
+	if err != nil {
+		return nil, err
+	}
+	return framework.NewIngressClient(httpAddr, httpsAddr), nil
+}
+
+// IngressHosts returns the hostnames of the cluster's Ingresses and Gateway API HTTPRoutes (wildcard hosts
+// are skipped; HTTPRoutes only when the CRD is installed).
+func IngressHosts(ctx context.Context, c ctrlClient.Client) ([]string, error) {
+	var hosts []string
+	seen := map[string]bool{}
+	add := func(h string) {
+		if h != "" && !strings.HasPrefix(h, "*") && !seen[h] {
+			seen[h] = true
+			hosts = append(hosts, h)
+		}
+	}
+	var ingresses networkingv1.IngressList
+	if err := c.List(ctx, &ingresses); err != nil {
+		return nil, err
+	}
+	for _, ing := range ingresses.Items {
+		for _, rule := range ing.Spec.Rules {
+			add(rule.Host)
+		}
+	}
+	routes := &unstructured.UnstructuredList{}
+	routes.SetAPIVersion("gateway.networking.k8s.io/v1")
+	routes.SetKind("HTTPRouteList")
+	if err := c.List(ctx, routes); err != nil && !apimeta.IsNoMatchError(err) {
+		return nil, err
+	}
+	for _, route := range routes.Items {
+		names, _, _ := unstructured.NestedStringSlice(route.Object, "spec", "hostnames")
+		for _, h := range names {
+			add(h)
+		}
+	}
+	return hosts, nil
+}
+
+// IngressServes requests url with client and returns an error unless a backend answered: connection
+// failures, 5xx (no ready endpoints) and 404 (no matching route) are errors; redirects and auth
+// challenges count as served.
+func IngressServes(client *http.Client, url string) error {
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return nil
+}
+
+// ExposeService creates (or updates) an Ingress named like svc that routes host to svc's first port through
+// the default IngressClass (the catalog's traefik sets isDefaultClass), for apps whose configuration has no
+// Ingress of their own.
+func ExposeService(ctx context.Context, c ctrlClient.Client, svc *corev1.Service, host string) error {
+	if len(svc.Spec.Ports) == 0 {
+		return fmt.Errorf("service %s/%s has no ports", svc.Namespace, svc.Name)
+	}
+	pathType := networkingv1.PathTypePrefix
+	ing := &networkingv1.Ingress{
+		TypeMeta:   metav1.TypeMeta{APIVersion: networkingv1.SchemeGroupVersion.String(), Kind: "Ingress"},
+		ObjectMeta: metav1.ObjectMeta{Name: svc.Name, Namespace: svc.Namespace},
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{{
+				Host: host,
+				IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+					Paths: []networkingv1.HTTPIngressPath{{
+						Path:     "/",
+						PathType: &pathType,
+						Backend: networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{
+							Name: svc.Name,
+							Port: networkingv1.ServiceBackendPort{Number: svc.Spec.Ports[0].Port},
+						}},
+					}},
+				}},
+			}},
+		},
+	}
+	return c.Patch(ctx, ing, ctrlClient.Apply, ctrlClient.ForceOwnership, ctrlClient.FieldOwner("catalog-apptests"))
+}
@@ -0,0 +1,45 @@
+package catalogapptests
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/framework"
+)
+
+var _ = Describe("Ingress NodePorts", Label("unit"), func() {
+	It("sets the traefik Service type and entrypoint NodePorts through HelmRelease values", func() {
+		hr := ingressHelmRelease()
+		Expect(hr.GetKind()).To(Equal("HelmRelease"))
+		Expect(hr.GetName()).To(Equal(IngressAppName))
+		Expect(hr.Object["spec"]).To(Equal(map[string]interface{}{"values": map[string]interface{}{
+			"service": map[string]interface{}{"type": "NodePort"},
+			"ports": map[string]interface{}{
+				"web":       map[string]interface{}{"nodePort": int64(framework.IngressHTTPNodePort)},
+				"websecure": map[string]interface{}{"nodePort": int64(framework.IngressHTTPSNodePort)},
+			},
+		}}))
+	})
+
+	It("waits until the Service exposes the NodePorts", func() {
+		svc := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "traefik-system", Name: "traefik", Labels: map[string]string{"app.kubernetes.io/name": IngressAppName}},
+			Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer, Ports: []corev1.ServicePort{
+				{Name: "web", Port: 80, NodePort: 31111},
+				{Name: "websecure", Port: 443, NodePort: framework.IngressHTTPSNodePort},
+			}},
+		}
+		ctx := context.Background()
+		c := &clusterImpl{client: fake.NewClientBuilder().WithScheme(framework.NewScheme()).WithObjects(svc).Build()}
+		Expect(c.ingressNodePortsReady(ctx)).To(MatchError(ContainSubstring("does not expose web and websecure")))
+
+		svc.Spec.Ports[0].NodePort = framework.IngressHTTPNodePort
+		Expect(c.client.Update(ctx, svc)).To(Succeed())
+		Expect(c.ingressNodePortsReady(ctx)).To(Succeed())
+	})
+})
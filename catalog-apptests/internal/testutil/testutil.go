@@ -0,0 +1,100 @@
+// Package testutil holds the fixtures the catalog-apptests package tests share: files, app versions under a
+// temporary applications/ directory and charts in a temporary chart cache. Failures are reported through
+// Gomega, so the helpers are called from Ginkgo specs.
+package testutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+// Registry is the OCI registry path fixture charts are referenced and cached under.
+const Registry = "registry.example.com/charts"
+
+// WriteFile writes content to path, creating its parent directories.
+func WriteFile(path, content string) {
+	Expect(os.MkdirAll(filepath.Dir(path), 0o755)).To(Succeed())
+	Expect(os.WriteFile(path, []byte(content), 0o644)).To(Succeed())
+}
+
+// Indent prefixes every line of s with n spaces.
+func Indent(s string, n int) string {
+	var b strings.Builder
+	for _, l := range strings.Split(strings.TrimSuffix(s, "\n"), "\n") {
+		b.WriteString(strings.Repeat(" ", n) + l + "\n")
+	}
+	return b.String()
+}
+
+// HelmRelease returns an OCIRepository ${releaseName}-chart for Registry/<chartName>:<version> and a
+// HelmRelease name installing it; spec (top-level spec fields, unindented) is appended to the HelmRelease spec.
+func HelmRelease(name, chartName, version, spec string) string {
+	hr := fmt.Sprintf(`apiVersion: source.toolkit.fluxcd.io/v1
+kind: OCIRepository
+metadata:
+  name: ${releaseName}-chart
+  namespace: ${releaseNamespace}
+spec:
+  ref:
+    tag: %s
+  url: oci://%s/%s
+---
+apiVersion: helm.toolkit.fluxcd.io/v2
+kind: HelmRelease
+metadata:
+  name: %s
+  namespace: ${releaseNamespace}
+spec:
+  chartRef:
+    kind: OCIRepository
+    name: ${releaseName}-chart
+`, version, Registry, chartName, name)
+	if spec != "" {
+		hr += Indent(spec, 2)
+	}
+	return hr
+}
+
+// DefaultsConfigMap returns the ${releaseName}-config-defaults ConfigMap holding values as values.yaml.
+func DefaultsConfigMap(values string) string {
+	return `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ${releaseName}-config-defaults
+  namespace: ${releaseNamespace}
+data:
+  values.yaml: |
+` + Indent(values, 4)
+}
+
+// WriteApp writes applications/<name>/<version>/helmrelease/ under appsDir: helmRelease as helmrelease.yaml,
+// cm (when set) as cm.yaml and a kustomization.yaml listing them. It returns the version directory.
+func WriteApp(appsDir, name, version, helmRelease, cm string) string {
+	versionDir := filepath.Join(appsDir, name, version)
+	dir := filepath.Join(versionDir, "helmrelease")
+	resources := "resources:\n- helmrelease.yaml\n"
+	if cm != "" {
+		resources = "resources:\n- cm.yaml\n- helmrelease.yaml\n"
+		WriteFile(filepath.Join(dir, "cm.yaml"), cm)
+	}
+	WriteFile(filepath.Join(dir, "kustomization.yaml"), resources)
+	WriteFile(filepath.Join(dir, "helmrelease.yaml"), helmRelease)
+	return versionDir
+}
+
+// CacheChart saves c where the chart cache under cacheDir expects Registry/<name>:<version> and returns the
+// archive path.
+func CacheChart(cacheDir string, c *chart.Chart) string {
+	dir := filepath.Join(cacheDir, filepath.FromSlash(Registry), c.Metadata.Name)
+	Expect(os.MkdirAll(dir, 0o755)).To(Succeed())
+	p, err := chartutil.Save(c, dir)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(filepath.Base(p)).To(Equal(c.Metadata.Name + "-" + c.Metadata.Version + ".tgz"))
+	return p
+}
@@ -0,0 +1,43 @@
+package catalogapptests
+
+import (
+	"os"
+	"strings"
+)
+
+// KubernetesVersionsEnv is a comma-separated list of Kubernetes versions (or Kind node images) the templated
+// suite runs each app against, e.g. "v1.31.0,v1.33.1". Unset runs once on Kind's default node image.
+// Node images are loaded from the local cache (framework.NodeImageCacheEnv) before pulling.
+const KubernetesVersionsEnv = "KIND_K8S_VERSIONS"
+
+// KubernetesVersions returns the versions from KubernetesVersionsEnv, or [""] (Kind's default) when unset.
+func KubernetesVersions() []string {
+	var out []string
+	for _, v := range strings.Split(os.Getenv(KubernetesVersionsEnv), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	if len(out) == 0 {
+		return []string{""}
+	}
+	return out
+}
+
+// KubernetesVersionLabel returns the Ginkgo label for a version ("v1.33.1" or "kindest/node:v1.33.1@sha256:…"
+// → "k8s-v1.33.1"); "" for Kind's default.
+func KubernetesVersionLabel(version string) string {
+	if version == "" {
+		return ""
+	}
+	if i := strings.Index(version, "@"); i >= 0 {
+		version = version[:i]
+	}
+	if i := strings.LastIndex(version, ":"); i >= 0 {
+		version = version[i+1:]
+	}
+	if !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+	return "k8s-" + version
+}
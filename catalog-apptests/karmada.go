@@ -0,0 +1,314 @@
+package catalogapptests
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/framework"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/clientcmd"
+	ctrlClient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Karmada hub-and-spoke scenario settings.
+const (
+	// KarmadaAppName is the catalog app that installs the Karmada operator on the management cluster.
+	KarmadaAppName = "karmada-operator"
+	// KarmadaNamespace is where CreateKarmada creates the Karmada instance.
+	KarmadaNamespace = "karmada-system"
+	// KarmadaInstanceName is the name of the Karmada instance CreateKarmada creates.
+	KarmadaInstanceName = "karmada"
+	// KarmadaMemberNamespace holds the member cluster credentials, in the Karmada API server and on members.
+	KarmadaMemberNamespace = "karmada-cluster"
+	// KarmadaTimeout bounds waiting for the Karmada instance and member clusters to become Ready.
+	KarmadaTimeout = 15 * time.Minute
+)
+
+// karmadaMemberServiceAccount is the service account Karmada uses on each member cluster.
+const karmadaMemberServiceAccount = "karmada-member"
+
+// Karmada is a Karmada control plane on a management cluster, reached through a port-forward to its API
+// server. Close it when done.
+type Karmada struct {
+	client ctrlClient.Client
+	fwd    *framework.PortForward
+}
+
+// Client returns a client of the Karmada API server (unstructured or built-in Kubernetes types).
+func (k *Karmada) Client() ctrlClient.Client { return k.client }
+
+// Close stops the port-forward to the Karmada API server.
+func (k *Karmada) Close() { k.fwd.Close() }
+
+// CreateKarmada creates the Karmada instance KarmadaInstanceName on mgmt (the KarmadaAppName operator must be
+// installed), waits until it is Ready and connects to its API server.
+func CreateKarmada(ctx context.Context, mgmt NKPManagementCluster) (*Karmada, error) {
+	c := mgmt.Client()
+	ns := &corev1.Namespace{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"}, ObjectMeta: metav1.ObjectMeta{Name: KarmadaNamespace}}
+	if err := c.Patch(ctx, ns, ctrlClient.Apply, ctrlClient.ForceOwnership, ctrlClient.FieldOwner("catalog-apptests")); err != nil {
+		return nil, fmt.Errorf("apply Namespace %s: %w", KarmadaNamespace, err)
+	}
+	instance := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "operator.karmada.io/v1alpha1",
+		"kind":       "Karmada",
+		"metadata":   map[string]interface{}{"name": KarmadaInstanceName, "namespace": KarmadaNamespace},
+		"spec": map[string]interface{}{
+			"components": map[string]interface{}{
+				"etcd":             map[string]interface{}{"local": map[string]interface{}{"volumeData": map[string]interface{}{"emptyDir": map[string]interface{}{}}}},
+				"karmadaAPIServer": map[string]interface{}{"serviceType": "ClusterIP"},
+			},
+		},
+	}}
+	if err := c.Patch(ctx, instance, ctrlClient.Apply, ctrlClient.ForceOwnership, ctrlClient.FieldOwner("catalog-apptests")); err != nil {
+		return nil, fmt.Errorf("apply Karmada %s: %w", KarmadaInstanceName, err)
+	}
+
+	var kubeconfig []byte
+	err := framework.Poll(ctx, PollInterval, KarmadaTimeout, func(ctx context.Context) error {
+		if err := c.Get(ctx, ctrlClient.ObjectKeyFromObject(instance), instance); err != nil {
+			return err
+		}
+		if err := unstructuredReady(instance); err != nil {
+			return err
+		}
+		var err error
+		kubeconfig, err = karmadaAdminKubeconfig(ctx, c, instance)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("karmada %s/%s: %w", KarmadaNamespace, KarmadaInstanceName, err)
+	}
+	return connectKarmada(ctx, mgmt, kubeconfig)
+}
+
+// karmadaAdminKubeconfig returns the admin kubeconfig the operator stores in the Secret of status.secretRef
+// (default <name>-admin-config).
+func karmadaAdminKubeconfig(ctx context.Context, c ctrlClient.Client, instance *unstructured.Unstructured) ([]byte, error) {
+	name, _, _ := unstructured.NestedString(instance.Object, "status", "secretRef", "name")
+	namespace, _, _ := unstructured.NestedString(instance.Object, "status", "secretRef", "namespace")
+	if name == "" {
+		name = instance.GetName() + "-admin-config"
+	}
+	if namespace == "" {
+		namespace = instance.GetNamespace()
+	}
+	var secret corev1.Secret
+	if err := c.Get(ctx, ctrlClient.ObjectKey{Namespace: namespace, Name: name}, &secret); err != nil {
+		return nil, err
+	}
+	for _, key := range []string{"kubeconfig", "karmada.config"} {
+		if b := secret.Data[key]; len(b) > 0 {
+			return b, nil
+		}
+	}
+	if len(secret.Data) == 1 {
+		for _, b := range secret.Data {
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("secret %s/%s has no kubeconfig", namespace, name)
+}
+
+// connectKarmada port-forwards to the API server Service named in kubeconfig's server URL
+// (https://<service>.<namespace>.svc...:<port>) and returns a client for it.
+func connectKarmada(ctx context.Context, mgmt Cluster, kubeconfig []byte) (*Karmada, error) {
+	cfg, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("karmada kubeconfig: %w", err)
+	}
+	server, err := url.Parse(cfg.Host)
+	if err != nil {
+		return nil, fmt.Errorf("karmada kubeconfig server %q: %w", cfg.Host, err)
+	}
+	labels := strings.Split(server.Hostname(), ".")
+	if len(labels) < 2 {
+		return nil, fmt.Errorf("karmada kubeconfig server %q is not a Service address", cfg.Host)
+	}
+	port := 443
+	if p := server.Port(); p != "" {
+		if port, err = strconv.Atoi(p); err != nil {
+			return nil, fmt.Errorf("karmada kubeconfig server %q: %w", cfg.Host, err)
+		}
+	}
+	fwd, err := mgmt.PortForwardService(ctx, labels[1], labels[0], port)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Host = "https://" + fwd.LocalAddress()
+	if cfg.TLSClientConfig.ServerName == "" {
+		cfg.TLSClientConfig.ServerName = server.Hostname()
+	}
+	c, err := ctrlClient.New(cfg, ctrlClient.Options{Scheme: framework.NewScheme()})
+	if err != nil {
+		fwd.Close()
+		return nil, fmt.Errorf("karmada client: %w", err)
+	}
+	return &Karmada{client: c, fwd: fwd}, nil
+}
+
+// Join registers workload as a Push-mode member cluster named like it: a cluster-admin service account token
+// is created on the workload and stored with the CA in the Karmada API server, and the Cluster object points
+// at the workload's in-network API endpoint (PeerCluster.PeerKubeconfig). Join waits until the member is Ready.
+func (k *Karmada) Join(ctx context.Context, workload NKPWorkloadCluster) error {
+	w, ok := workload.(PeerCluster)
+	if !ok {
+		return fmt.Errorf("Join: %T does not implement PeerCluster", workload)
+	}
+	name := w.Name()
+	peer, err := w.PeerKubeconfig()
+	if err != nil {
+		return err
+	}
+	peerCfg, err := clientcmd.RESTConfigFromKubeConfig(peer)
+	if err != nil {
+		return fmt.Errorf("%s peer kubeconfig: %w", name, err)
+	}
+	token, err := memberToken(ctx, workload.Client())
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	objs := []ctrlClient.Object{
+		&corev1.Namespace{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"}, ObjectMeta: metav1.ObjectMeta{Name: KarmadaMemberNamespace}},
+		&corev1.Secret{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: KarmadaMemberNamespace},
+			Data:       map[string][]byte{"token": token, "caBundle": peerCfg.CAData},
+		},
+		&corev1.Secret{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+			ObjectMeta: metav1.ObjectMeta{Name: name + "-impersonator", Namespace: KarmadaMemberNamespace},
+			Data:       map[string][]byte{"token": token},
+		},
+		&unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "cluster.karmada.io/v1alpha1",
+			"kind":       "Cluster",
+			"metadata":   map[string]interface{}{"name": name},
+			"spec": map[string]interface{}{
+				"apiEndpoint":           peerCfg.Host,
+				"syncMode":              "Push",
+				"secretRef":             map[string]interface{}{"namespace": KarmadaMemberNamespace, "name": name},
+				"impersonatorSecretRef": map[string]interface{}{"namespace": KarmadaMemberNamespace, "name": name + "-impersonator"},
+			},
+		}},
+	}
+	for _, obj := range objs {
+		if err := k.client.Patch(ctx, obj, ctrlClient.Apply, ctrlClient.ForceOwnership, ctrlClient.FieldOwner("catalog-apptests")); err != nil {
+			return fmt.Errorf("join %s: apply %T %s: %w", name, obj, obj.GetName(), err)
+		}
+	}
+
+	member := &unstructured.Unstructured{}
+	member.SetAPIVersion("cluster.karmada.io/v1alpha1")
+	member.SetKind("Cluster")
+	return framework.Poll(ctx, PollInterval, KarmadaTimeout, func(ctx context.Context) error {
+		if err := k.client.Get(ctx, ctrlClient.ObjectKey{Name: name}, member); err != nil {
+			return err
+		}
+		if err := unstructuredReady(member); err != nil {
+			return fmt.Errorf("member %s: %w", name, err)
+		}
+		return nil
+	})
+}
+
+// memberToken creates the karmadaMemberServiceAccount with cluster-admin on a member cluster and returns
+// its long-lived token.
+func memberToken(ctx context.Context, c ctrlClient.Client) ([]byte, error) {
+	objs := []ctrlClient.Object{
+		&corev1.Namespace{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"}, ObjectMeta: metav1.ObjectMeta{Name: KarmadaMemberNamespace}},
+		&corev1.ServiceAccount{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+			ObjectMeta: metav1.ObjectMeta{Name: karmadaMemberServiceAccount, Namespace: KarmadaMemberNamespace},
+		},
+		&rbacv1.ClusterRoleBinding{
+			TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRoleBinding"},
+			ObjectMeta: metav1.ObjectMeta{Name: karmadaMemberServiceAccount},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "cluster-admin"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: karmadaMemberServiceAccount, Namespace: KarmadaMemberNamespace}},
+		},
+		&corev1.Secret{
+			TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        karmadaMemberServiceAccount + "-token",
+				Namespace:   KarmadaMemberNamespace,
+				Annotations: map[string]string{corev1.ServiceAccountNameKey: karmadaMemberServiceAccount},
+			},
+			Type: corev1.SecretTypeServiceAccountToken,
+		},
+	}
+	for _, obj := range objs {
+		if err := c.Patch(ctx, obj, ctrlClient.Apply, ctrlClient.ForceOwnership, ctrlClient.FieldOwner("catalog-apptests")); err != nil {
+			return nil, fmt.Errorf("apply %T %s: %w", obj, obj.GetName(), err)
+		}
+	}
+	var token []byte
+	err := framework.Poll(ctx, PollInterval, time.Minute, func(ctx context.Context) error {
+		var secret corev1.Secret
+		if err := c.Get(ctx, ctrlClient.ObjectKey{Namespace: KarmadaMemberNamespace, Name: karmadaMemberServiceAccount + "-token"}, &secret); err != nil {
+			return err
+		}
+		if token = secret.Data[corev1.ServiceAccountTokenKey]; len(token) == 0 {
+			return fmt.Errorf("service account token not populated yet")
+		}
+		return nil
+	})
+	return token, err
+}
+
+// Propagate applies obj to the Karmada API server together with a PropagationPolicy of the same name that
+// places it on the given member clusters.
+func (k *Karmada) Propagate(ctx context.Context, obj *unstructured.Unstructured, clusters ...string) error {
+	if err := k.client.Patch(ctx, obj, ctrlClient.Apply, ctrlClient.ForceOwnership, ctrlClient.FieldOwner("catalog-apptests")); err != nil {
+		return fmt.Errorf("apply %s %s: %w", obj.GetKind(), obj.GetName(), err)
+	}
+	names := make([]interface{}, 0, len(clusters))
+	for _, c := range clusters {
+		names = append(names, c)
+	}
+	policy := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "policy.karmada.io/v1alpha1",
+		"kind":       "PropagationPolicy",
+		"metadata":   map[string]interface{}{"name": obj.GetName(), "namespace": obj.GetNamespace()},
+		"spec": map[string]interface{}{
+			"resourceSelectors": []interface{}{map[string]interface{}{
+				"apiVersion": obj.GetAPIVersion(),
+				"kind":       obj.GetKind(),
+				"name":       obj.GetName(),
+			}},
+			"placement": map[string]interface{}{"clusterAffinity": map[string]interface{}{"clusterNames": names}},
+		},
+	}}
+	if err := k.client.Patch(ctx, policy, ctrlClient.Apply, ctrlClient.ForceOwnership, ctrlClient.FieldOwner("catalog-apptests")); err != nil {
+		return fmt.Errorf("apply PropagationPolicy %s: %w", policy.GetName(), err)
+	}
+	return nil
+}
+
+// unstructuredReady returns nil when obj has a Ready condition (see readyCondition) with status True.
+func unstructuredReady(obj *unstructured.Unstructured) error {
+	var status struct {
+		Conditions []metav1.Condition `json:"conditions"`
+	}
+	if m, ok, _ := unstructured.NestedMap(obj.Object, "status"); ok {
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, &status); err != nil {
+			return fmt.Errorf("%s %s status: %w", obj.GetKind(), obj.GetName(), err)
+		}
+	}
+	ready := readyCondition(status.Conditions)
+	switch {
+	case ready == nil:
+		return fmt.Errorf("%s %s has no Ready condition yet", obj.GetKind(), obj.GetName())
+	case ready.Status != metav1.ConditionTrue:
+		return fmt.Errorf("%s %s not ready: %s: %s", obj.GetKind(), obj.GetName(), ready.Reason, ready.Message)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package catalogapptests
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"os"
+	"sync"
+
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/framework"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlClient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// LoadBalancerEnv, when "true", makes the suite install LoadBalancerApp on every cluster after Flux and check
+// that the app's LoadBalancer Services get an address reachable from the test process.
+const LoadBalancerEnv = "CATALOG_LOAD_BALANCER"
+
+// LoadBalancerPoolSize is the number of addresses NewLoadBalancerApp gives each cluster per IP family.
+const LoadBalancerPoolSize = 16
+
+// Ensure compile-time implementation.
+var _ App = loadBalancerApp{}
+
+// loadBalancerApp installs MetalLB with an address pool from the cluster's Docker network.
+type loadBalancerApp struct {
+	options  framework.MetalLBOptions
+	poolSize int
+}
+
+// LoadBalancerOption configures NewLoadBalancerApp.
+type LoadBalancerOption func(*loadBalancerApp)
+
+// WithMetalLBVersion installs the given MetalLB release (e.g. "v0.14.9").
+func WithMetalLBVersion(version string) LoadBalancerOption {
+	return func(a *loadBalancerApp) { a.options.Version = version }
+}
+
+// WithAddressPoolSize gives each cluster size addresses per IP family (default LoadBalancerPoolSize).
+func WithAddressPoolSize(size int) LoadBalancerOption {
+	return func(a *loadBalancerApp) { a.poolSize = size }
+}
+
+// WithAddressPool uses the given address ranges ("first-last" or CIDR) instead of carving the pool from the
+// cluster's network.
+func WithAddressPool(addresses ...string) LoadBalancerOption {
+	return func(a *loadBalancerApp) { a.options.Addresses = append(a.options.Addresses, addresses...) }
+}
+
+// NewLoadBalancerApp returns an App that installs MetalLB (L2 mode) so LoadBalancer Services get an address.
+// The pool is a block from the top of each of the cluster network's subnets (of the cluster's IP family);
+// clusters on the same network get distinct blocks. On Linux the addresses are reachable from the host.
+func NewLoadBalancerApp(opts ...LoadBalancerOption) App {
+	a := loadBalancerApp{poolSize: LoadBalancerPoolSize}
+	for _, opt := range opts {
+		opt(&a)
+	}
+	return a
+}
+
+// LoadBalancerApp is the default load balancer app instance (e.g. cluster.Install(LoadBalancerApp)).
+var LoadBalancerApp App = NewLoadBalancerApp()
+
+// LoadBalancerEnabled reports whether LoadBalancerEnv is "true".
+func LoadBalancerEnabled() bool {
+	return os.Getenv(LoadBalancerEnv) == "true"
+}
+
+func (a loadBalancerApp) InstallOn(cluster Cluster) error {
+	impl, ok := cluster.(*clusterImpl)
+	if !ok {
+		return fmt.Errorf("LoadBalancerApp requires cluster from KindCluster")
+	}
+	options := a.options
+	if len(options.Addresses) == 0 {
+		addresses, err := impl.loadBalancerAddresses(impl.ctx, a.poolSize)
+		if err != nil {
+			return err
+		}
+		options.Addresses = addresses
+	}
+	return framework.InstallMetalLB(impl.ctx, impl.handle.KubeconfigFilePath(), options)
+}
+
+// loadBalancerBlocks hands out address block indexes per Docker network, so clusters sharing a network
+// (management and workload clusters) get non-overlapping pools.
+var loadBalancerBlocks = struct {
+	sync.Mutex
+	next map[string]int
+}{next: map[string]int{}}
+
+// loadBalancerAddresses carves a block of size addresses for the cluster from each subnet of its network
+// that matches the cluster's IP family.
+func (c *clusterImpl) loadBalancerAddresses(ctx context.Context, size int) ([]string, error) {
+	network := c.network
+	if network == nil || len(network.Subnets) == 0 {
+		var err error
+		if network, err = framework.InspectNetwork(ctx, c.networkName); err != nil {
+			return nil, err
+		}
+	}
+	loadBalancerBlocks.Lock()
+	index := loadBalancerBlocks.next[network.Name]
+	loadBalancerBlocks.next[network.Name]++
+	loadBalancerBlocks.Unlock()
+
+	var addresses []string
+	for _, s := range network.Subnets {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return nil, fmt.Errorf("network %s subnet %q: %w", network.Name, s, err)
+		}
+		if (p.Addr().Is4() && c.ipFamily == framework.IPFamilyIPv6) || (p.Addr().Is6() && c.ipFamily != framework.IPFamilyIPv6 && c.ipFamily != framework.IPFamilyDual) {
+			continue
+		}
+		r, err := framework.LoadBalancerAddressRange(s, index, size)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, r)
+	}
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("network %s has no subnet for IP family %q", network.Name, c.ipFamily)
+	}
+	return addresses, nil
+}
+
+// LoadBalancerAddress returns the first ingress IP (or hostname) of the LoadBalancer Service namespace/name,
+// or an error while it is pending.
+func LoadBalancerAddress(ctx context.Context, c ctrlClient.Client, namespace, name string) (string, error) {
+	var svc corev1.Service
+	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &svc); err != nil {
+		return "", err
+	}
+	if svc.Spec.Type != corev1.ServiceTypeLoadBalancer {
+		return "", fmt.Errorf("service %s/%s has type %s, not LoadBalancer", namespace, name, svc.Spec.Type)
+	}
+	for _, ing := range svc.Status.LoadBalancer.Ingress {
+		if ing.IP != "" {
+			return ing.IP, nil
+		}
+		if ing.Hostname != "" {
+			return ing.Hostname, nil
+		}
+	}
+	return "", fmt.Errorf("service %s/%s: load balancer address pending", namespace, name)
+}
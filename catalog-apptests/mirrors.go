@@ -0,0 +1,15 @@
+package catalogapptests
+
+import "os"
+
+// RegistryMirrorsEnv, when "true", makes the suite start registry mirrors with the suite network, before its
+// first cluster (framework.NewRegistryMirrors), and create every cluster with ClusterConfig.RegistryMirrors.
+const RegistryMirrorsEnv = "CATALOG_REGISTRY_MIRRORS"
+
+// RegistryMirrorsPrefix prefixes the suite's mirror container and volume names (<prefix>-mirror-<host>).
+const RegistryMirrorsPrefix = "catalog-apptests"
+
+// RegistryMirrorsEnabled reports whether RegistryMirrorsEnv is "true".
+func RegistryMirrorsEnabled() bool {
+	return os.Getenv(RegistryMirrorsEnv) == "true"
+}
@@ -0,0 +1,93 @@
+package catalogapptests
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/framework"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlClient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// OpenCostAPIPort is the port of the OpenCost exporter Service (API and /healthz).
+const OpenCostAPIPort = 9003
+
+// Workload cluster Secrets written by RegisterWorkloadCluster.
+const (
+	// WorkloadKubeconfigSecretSuffix is appended to the workload cluster name for its Secret name.
+	WorkloadKubeconfigSecretSuffix = "-kubeconfig"
+	// KubeconfigSecretKey holds the kubeconfig (Flux spec.kubeConfig.secretRef and Cluster API default).
+	KubeconfigSecretKey = "value"
+	// KarmadaKubeconfigSecretKey holds the same kubeconfig under the key Karmada member clusters use.
+	KarmadaKubeconfigSecretKey = "kubeconfig"
+)
+
+// probePodTimeout bounds how long HTTPGetFromPod waits for its probe pod.
+const probePodTimeout = 2 * time.Minute
+
+// AppService returns the Service labelled app.kubernetes.io/name=appName (any namespace) that has port.
+func AppService(ctx context.Context, c ctrlClient.Client, appName string, port int) (*corev1.Service, error) {
+	var services corev1.ServiceList
+	if err := c.List(ctx, &services, ctrlClient.MatchingLabels{"app.kubernetes.io/name": appName}); err != nil {
+		return nil, err
+	}
+	for i := range services.Items {
+		for _, p := range services.Items[i].Spec.Ports {
+			if int(p.Port) == port {
+				return &services.Items[i], nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no %s Service with port %d", appName, port)
+}
+
+func (c *clusterImpl) ExposeServiceOnNetwork(ctx context.Context, namespace, name string, port int) (string, error) {
+	return framework.ExposeServiceOnNetwork(ctx, c.handle.KubeconfigFilePath(), namespace, name, port)
+}
+
+func (c *clusterImpl) RegisterWorkloadCluster(ctx context.Context, workload NKPWorkloadCluster) (string, error) {
+	return c.registerWorkloadCluster(ctx, workload, DefaultNamespace)
+}
+
+// registerWorkloadCluster writes the workload's kubeconfig Secret (see RegisterWorkloadCluster) into namespace.
+func (c *clusterImpl) registerWorkloadCluster(ctx context.Context, workload NKPWorkloadCluster, namespace string) (string, error) {
+	w, ok := workload.(PeerCluster)
+	if !ok {
+		return "", fmt.Errorf("RegisterWorkloadCluster: %T does not implement PeerCluster", workload)
+	}
+	if w.NetworkName() != c.networkName {
+		return "", fmt.Errorf("RegisterWorkloadCluster: %s is on network %s, not %s", w.Name(), w.NetworkName(), c.networkName)
+	}
+	kubeconfig, err := w.PeerKubeconfig()
+	if err != nil {
+		return "", err
+	}
+	secret := &corev1.Secret{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{Name: w.Name() + WorkloadKubeconfigSecretSuffix, Namespace: namespace},
+		Type:       corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			KubeconfigSecretKey:        kubeconfig,
+			KarmadaKubeconfigSecretKey: kubeconfig,
+		},
+	}
+	if err := c.client.Patch(ctx, secret, ctrlClient.Apply, ctrlClient.ForceOwnership, ctrlClient.FieldOwner("catalog-apptests")); err != nil {
+		return "", fmt.Errorf("apply Secret %s: %w", secret.Name, err)
+	}
+	return secret.Name, nil
+}
+
+// PeerKubeconfig returns the in-network kubeconfig of c's handle, which must be a PeerKubeconfigProvider.
+func (c *clusterImpl) PeerKubeconfig() ([]byte, error) {
+	p, ok := c.handle.(PeerKubeconfigProvider)
+	if !ok {
+		return nil, fmt.Errorf("cluster %s: %T does not provide a peer kubeconfig (PeerKubeconfigProvider)", c.name, c.handle)
+	}
+	return p.PeerKubeconfig()
+}
+
+func (c *clusterImpl) HTTPGetFromPod(ctx context.Context, url string) (string, error) {
+	return framework.RunHTTPGetPod(ctx, c.handle.KubeconfigFilePath(), DefaultNamespace, url, probePodTimeout)
+}
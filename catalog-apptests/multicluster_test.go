@@ -0,0 +1,32 @@
+package catalogapptests
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ = DescribeTable("unstructuredReady", Label("unit"),
+	func(status map[string]interface{}, want string) {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{"kind": "Cluster", "metadata": map[string]interface{}{"name": "w1"}}}
+		if status != nil {
+			obj.Object["status"] = status
+		}
+		err := unstructuredReady(obj)
+		if want == "" {
+			Expect(err).ToNot(HaveOccurred())
+		} else {
+			Expect(err).To(MatchError(want))
+		}
+	},
+	Entry("no status", nil, "Cluster w1 has no Ready condition yet"),
+	Entry("no Ready condition", map[string]interface{}{"conditions": []interface{}{
+		map[string]interface{}{"type": "Synced", "status": "True"},
+	}}, "Cluster w1 has no Ready condition yet"),
+	Entry("Ready", map[string]interface{}{"conditions": []interface{}{
+		map[string]interface{}{"type": "Ready", "status": "True", "lastTransitionTime": "2026-01-01T00:00:00Z"},
+	}}, ""),
+	Entry("not Ready", map[string]interface{}{"conditions": []interface{}{
+		map[string]interface{}{"type": "Ready", "status": "False", "reason": "ClusterNotReachable", "message": "timeout"},
+	}}, "Cluster w1 not ready: ClusterNotReachable: timeout"),
+)
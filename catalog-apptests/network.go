@@ -0,0 +1,40 @@
+package catalogapptests
+
+import (
+	"os"
+	"strings"
+
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/framework"
+)
+
+// Suite network settings, read by SuiteNetworkConfig.
+const (
+	// NetworkSubnetsEnv lists the network's subnets (comma-separated IPv4/IPv6 CIDRs), or "auto" to allocate
+	// subnets that overlap no existing Docker network.
+	NetworkSubnetsEnv = "CATALOG_NETWORK_SUBNETS"
+	// NetworkIPFamilyEnv is the network and cluster IP family: ipv4 (default), ipv6 or dual.
+	NetworkIPFamilyEnv = "CATALOG_NETWORK_IP_FAMILY"
+	// NetworkCleanupEnv, when "true", deletes the network on suite teardown if the suite created it.
+	NetworkCleanupEnv = "CATALOG_NETWORK_CLEANUP"
+)
+
+// SuiteNetworkConfig returns the suite's Docker network settings from the environment.
+func SuiteNetworkConfig() framework.NetworkConfig {
+	cfg := framework.NetworkConfig{IPFamily: framework.IPFamily(os.Getenv(NetworkIPFamilyEnv))}
+	subnets := os.Getenv(NetworkSubnetsEnv)
+	if subnets == "auto" {
+		cfg.AllocateSubnets = true
+		return cfg
+	}
+	for _, s := range strings.Split(subnets, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			cfg.Subnets = append(cfg.Subnets, s)
+		}
+	}
+	return cfg
+}
+
+// NetworkCleanupEnabled reports whether NetworkCleanupEnv is "true".
+func NetworkCleanupEnabled() bool {
+	return os.Getenv(NetworkCleanupEnv) == "true"
+}
@@ -0,0 +1,127 @@
+package catalogapptests
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+
+	"sigs.k8s.io/yaml"
+)
+
+// PlacementsEnv, when set, is the path of a placements file used by the suite instead of the built-in
+// placements.yaml.
+const PlacementsEnv = "CATALOG_PLACEMENTS"
+
+//go:embed placements.yaml
+var defaultPlacements []byte
+
+// Placements is the content of a placements file: which apps are central, which are clients and how they pair.
+type Placements struct {
+	Pairs []AppPair `json:"pairs"`
+}
+
+// AppPair is a central app and the client app paired with it.
+type AppPair struct {
+	// Name identifies the pair in spec names and labels.
+	Name string `json:"name"`
+	// Central is the app installed on management clusters.
+	Central string `json:"central"`
+	// Client is the app installed on workload clusters.
+	Client string `json:"client"`
+	// Endpoint, when set, is the central app's endpoint every workload must reach over the shared network.
+	Endpoint *PairEndpoint `json:"endpoint,omitempty"`
+	// CentralChecks are HTTP endpoints of the central app, requested on the management cluster once the
+	// clients run, that must eventually answer with a 2xx response (e.g. data aggregated from the workloads).
+	CentralChecks []PairCheck `json:"centralChecks,omitempty"`
+}
+
+// PairEndpoint is an HTTP endpoint on the Service labelled app.kubernetes.io/name=<Service> (see AppService).
+type PairEndpoint struct {
+	Service string `json:"service"`
+	Port    int    `json:"port"`
+	Path    string `json:"path"`
+}
+
+// PairCheck is a PairEndpoint whose response body must contain BodyContains (when set).
+type PairCheck struct {
+	PairEndpoint `json:",inline"`
+	BodyContains string `json:"bodyContains,omitempty"`
+}
+
+// DefaultPlacements returns the placements shipped with catalog-apptests (placements.yaml).
+func DefaultPlacements() (*Placements, error) {
+	return parsePlacements(defaultPlacements, "placements.yaml")
+}
+
+// LoadPlacements reads placements from path.
+func LoadPlacements(path string) (*Placements, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parsePlacements(b, path)
+}
+
+// SuitePlacements returns the placements from PlacementsEnv, or DefaultPlacements.
+func SuitePlacements() (*Placements, error) {
+	if path := os.Getenv(PlacementsEnv); path != "" {
+		return LoadPlacements(path)
+	}
+	return DefaultPlacements()
+}
+
+func parsePlacements(b []byte, source string) (*Placements, error) {
+	var p Placements
+	if err := yaml.UnmarshalStrict(b, &p); err != nil {
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+	seen := map[string]bool{}
+	for _, pair := range p.Pairs {
+		if pair.Name == "" || pair.Central == "" || pair.Client == "" {
+			return nil, fmt.Errorf("%s: every pair needs a name, a central and a client app", source)
+		}
+		if pair.Central == pair.Client {
+			return nil, fmt.Errorf("%s: pair %s: central and client are both %s", source, pair.Name, pair.Central)
+		}
+		if seen[pair.Name] {
+			return nil, fmt.Errorf("%s: duplicate pair %s", source, pair.Name)
+		}
+		seen[pair.Name] = true
+		if e := pair.Endpoint; e != nil && (e.Service == "" || e.Port <= 0) {
+			return nil, fmt.Errorf("%s: pair %s: endpoint needs a service and a port", source, pair.Name)
+		}
+		for _, check := range pair.CentralChecks {
+			if check.Service == "" || check.Port <= 0 {
+				return nil, fmt.Errorf("%s: pair %s: central checks need a service and a port", source, pair.Name)
+			}
+		}
+	}
+	return &p, nil
+}
+
+// PairsFor returns the pairs with a central or client app among apps (e.g. the changed apps of ChangedApps).
+func (p *Placements) PairsFor(apps []AppVersions) []AppPair {
+	names := map[string]bool{}
+	for _, av := range apps {
+		names[av.Name] = true
+	}
+	var pairs []AppPair
+	for _, pair := range p.Pairs {
+		if names[pair.Central] || names[pair.Client] {
+			pairs = append(pairs, pair)
+		}
+	}
+	return pairs
+}
+
+// AppFor returns the pair's app for a cluster role: Central on management clusters, Client on workload clusters.
+func (p AppPair) AppFor(role ClusterRole) (string, error) {
+	switch role {
+	case ClusterRoleManagement:
+		return p.Central, nil
+	case ClusterRoleWorkload:
+		return p.Client, nil
+	default:
+		return "", fmt.Errorf("pair %s has no app for %s clusters", p.Name, role)
+	}
+}
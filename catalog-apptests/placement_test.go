@@ -0,0 +1,76 @@
+package catalogapptests
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Placements", Label("unit"), func() {
+	It("parses the built-in placements", func() {
+		p, err := DefaultPlacements()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(p.Pairs).ToNot(BeEmpty())
+	})
+
+	It("parses endpoints and central checks", func() {
+		p, err := parsePlacements([]byte(`pairs:
+  - name: opencost
+    central: centralized-opencost
+    client: opencost
+    endpoint: {service: opencost, port: 9003, path: /healthz}
+    centralChecks:
+      - {service: opencost, port: 9003, path: /allocation, bodyContains: '"name":'}
+`), "placements.yaml")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(p.Pairs).To(Equal([]AppPair{{
+			Name:     "opencost",
+			Central:  "centralized-opencost",
+			Client:   "opencost",
+			Endpoint: &PairEndpoint{Service: "opencost", Port: 9003, Path: "/healthz"},
+			CentralChecks: []PairCheck{{
+				PairEndpoint: PairEndpoint{Service: "opencost", Port: 9003, Path: "/allocation"},
+				BodyContains: `"name":`,
+			}},
+		}}))
+	})
+
+	DescribeTable("rejects invalid placements",
+		func(content, want string) {
+			_, err := parsePlacements([]byte(content), "placements.yaml")
+			Expect(err).To(MatchError(ContainSubstring(want)))
+		},
+		Entry("unknown field", "pairs:\n  - name: a\n    central: c\n    client: w\n    role: central\n",
+			`placements.yaml: error unmarshaling JSON`),
+		Entry("missing client", "pairs:\n  - name: a\n    central: c\n",
+			"placements.yaml: every pair needs a name, a central and a client app"),
+		Entry("central is the client", "pairs:\n  - name: a\n    central: c\n    client: c\n",
+			"placements.yaml: pair a: central and client are both c"),
+		Entry("duplicate name", "pairs:\n  - name: a\n    central: c\n    client: w\n  - name: a\n    central: c2\n    client: w2\n",
+			"placements.yaml: duplicate pair a"),
+		Entry("endpoint without port", "pairs:\n  - name: a\n    central: c\n    client: w\n    endpoint: {service: s}\n",
+			"placements.yaml: pair a: endpoint needs a service and a port"),
+		Entry("central check without service", "pairs:\n  - name: a\n    central: c\n    client: w\n    centralChecks: [{port: 80}]\n",
+			"placements.yaml: pair a: central checks need a service and a port"),
+	)
+
+	It("selects the pairs with a central or client app among apps", func() {
+		p := &Placements{Pairs: []AppPair{
+			{Name: "a", Central: "a-central", Client: "a-client"},
+			{Name: "b", Central: "b-central", Client: "b-client"},
+			{Name: "c", Central: "c-central", Client: "c-client"},
+		}}
+		pairs := p.PairsFor([]AppVersions{{Name: "a-client"}, {Name: "c-central"}, {Name: "unrelated"}})
+		Expect(pairs).To(HaveLen(2))
+		Expect(pairs[0].Name).To(Equal("a"))
+		Expect(pairs[1].Name).To(Equal("c"))
+		Expect(p.PairsFor(nil)).To(BeEmpty())
+	})
+
+	It("returns the pair's app for a cluster role", func() {
+		pair := AppPair{Name: "a", Central: "a-central", Client: "a-client"}
+		Expect(pair.AppFor(ClusterRoleManagement)).To(Equal("a-central"))
+		Expect(pair.AppFor(ClusterRoleWorkload)).To(Equal("a-client"))
+		_, err := pair.AppFor(ClusterRole("edge"))
+		Expect(err).To(MatchError("pair a has no app for edge clusters"))
+	})
+})
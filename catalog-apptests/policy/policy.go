@@ -0,0 +1,366 @@
+// Package policy evaluates a repo-level CEL policy set (policies.yaml) against each app version's rendered
+// objects, with per-app exceptions in applications/<app>/.catalog-policy.yaml.
+package policy
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	catalogapptests "github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests"
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/render"
+)
+
+// ExceptionsFile is the per-app allow-list, next to .catalog-source.yaml.
+const ExceptionsFile = ".catalog-policy.yaml"
+
+//go:embed policies.yaml
+var defaultPolicies []byte
+
+// PolicySet is a list of policies (policies.yaml).
+type PolicySet struct {
+	Policies []Policy `json:"policies"`
+}
+
+// Policy is a named set of CEL validations applied to objects of the given kinds (all objects if empty).
+type Policy struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description,omitempty"`
+	Kinds       []string     `json:"kinds,omitempty"`
+	Validations []Validation `json:"validations"`
+}
+
+// Validation is a CEL expression that must evaluate to true. MessageExpression (a CEL string) or Message
+// describes a failure.
+type Validation struct {
+	Expression        string `json:"expression"`
+	Message           string `json:"message,omitempty"`
+	MessageExpression string `json:"messageExpression,omitempty"`
+}
+
+// DefaultPolicySet returns the policy set shipped with catalog-apptests (policy/policies.yaml).
+func DefaultPolicySet() (*PolicySet, error) {
+	return parsePolicySet(defaultPolicies, "policies.yaml")
+}
+
+// LoadPolicySet reads a policy set from path.
+func LoadPolicySet(path string) (*PolicySet, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parsePolicySet(b, path)
+}
+
+func parsePolicySet(b []byte, source string) (*PolicySet, error) {
+	var ps PolicySet
+	if err := yaml.UnmarshalStrict(b, &ps); err != nil {
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+	seen := map[string]bool{}
+	for _, p := range ps.Policies {
+		if p.Name == "" || len(p.Validations) == 0 {
+			return nil, fmt.Errorf("%s: every policy needs a name and at least one validation", source)
+		}
+		if seen[p.Name] {
+			return nil, fmt.Errorf("%s: duplicate policy %s", source, p.Name)
+		}
+		seen[p.Name] = true
+	}
+	return &ps, nil
+}
+
+// Exceptions is the content of applications/<app>/.catalog-policy.yaml.
+type Exceptions struct {
+	Exceptions []Exception `json:"exceptions"`
+}
+
+// Exception allows an app to violate a policy.
+type Exception struct {
+	Policy string `json:"policy"`
+	// Objects restricts the exception to Kind/name or Kind/namespace/name; empty means all of the app's objects.
+	Objects []string `json:"objects,omitempty"`
+	// Reason documents why the app needs the exception (required).
+	Reason string `json:"reason"`
+}
+
+// LoadExceptions reads appDir/.catalog-policy.yaml; it returns nil if the app has none.
+func LoadExceptions(appDir string) (*Exceptions, error) {
+	path := filepath.Join(appDir, ExceptionsFile)
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ex Exceptions
+	if err := yaml.UnmarshalStrict(b, &ex); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for _, e := range ex.Exceptions {
+		if e.Policy == "" || strings.TrimSpace(e.Reason) == "" {
+			return nil, fmt.Errorf("%s: every exception needs a policy and a reason", path)
+		}
+	}
+	return &ex, nil
+}
+
+func (e Exception) matches(obj *unstructured.Unstructured) bool {
+	if len(e.Objects) == 0 {
+		return true
+	}
+	for _, ref := range e.Objects {
+		switch parts := strings.Split(ref, "/"); len(parts) {
+		case 2:
+			if parts[0] == obj.GetKind() && parts[1] == obj.GetName() {
+				return true
+			}
+		case 3:
+			if parts[0] == obj.GetKind() && parts[1] == obj.GetNamespace() && parts[2] == obj.GetName() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Violation is one object failing one policy.
+type Violation struct {
+	App       string
+	Version   string
+	Policy    string
+	Kind      string
+	Namespace string
+	Name      string
+	Message   string
+}
+
+func (v Violation) String() string {
+	obj := v.Kind + "/" + v.Name
+	if v.Namespace != "" {
+		obj = v.Kind + "/" + v.Namespace + "/" + v.Name
+	}
+	return fmt.Sprintf("%s/%s: %s %s: %s", v.App, v.Version, v.Policy, obj, v.Message)
+}
+
+// Checker evaluates the policy set against catalog app versions.
+type Checker interface {
+	// Check renders applications/<app>/<version> (empty version = latest) and evaluates its objects.
+	Check(ctx context.Context, appName, version string) ([]Violation, error)
+	// Evaluate evaluates already rendered objects of an app version, applying the app's exceptions.
+	Evaluate(appName, version string, objs []*unstructured.Unstructured) ([]Violation, error)
+}
+
+// Ensure checker implements Checker at compile time.
+var _ Checker = (*checker)(nil)
+
+type checker struct {
+	catalog  catalogapptests.Catalog
+	renderer render.Renderer
+	policies *PolicySet
+	compiled map[string][]compiledValidation
+}
+
+type compiledValidation struct {
+	Validation
+	expr    cel.Program
+	message cel.Program
+}
+
+// Option configures a Checker.
+type Option func(*checker)
+
+// WithPolicySet replaces the default policy set.
+func WithPolicySet(ps *PolicySet) Option {
+	return func(c *checker) { c.policies = ps }
+}
+
+// NewChecker compiles the policy set and returns a Checker rendering app versions with renderer.
+func NewChecker(cat catalogapptests.Catalog, renderer render.Renderer, opts ...Option) (Checker, error) {
+	c := &checker{catalog: cat, renderer: renderer}
+	for _, o := range opts {
+		o(c)
+	}
+	if c.policies == nil {
+		ps, err := DefaultPolicySet()
+		if err != nil {
+			return nil, err
+		}
+		c.policies = ps
+	}
+	env, err := cel.NewEnv(
+		cel.Variable("object", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("podSpec", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("containers", cel.ListType(cel.MapType(cel.StringType, cel.DynType))),
+		ext.Strings(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	c.compiled = map[string][]compiledValidation{}
+	for _, p := range c.policies.Policies {
+		for _, v := range p.Validations {
+			cv := compiledValidation{Validation: v}
+			if cv.expr, err = compile(env, v.Expression, cel.BoolType); err != nil {
+				return nil, fmt.Errorf("policy %s: expression: %w", p.Name, err)
+			}
+			if v.MessageExpression != "" {
+				if cv.message, err = compile(env, v.MessageExpression, cel.StringType); err != nil {
+					return nil, fmt.Errorf("policy %s: messageExpression: %w", p.Name, err)
+				}
+			}
+			c.compiled[p.Name] = append(c.compiled[p.Name], cv)
+		}
+	}
+	return c, nil
+}
+
+func compile(env *cel.Env, expr string, want *cel.Type) (cel.Program, error) {
+	ast, iss := env.Compile(expr)
+	if iss.Err() != nil {
+		return nil, iss.Err()
+	}
+	if t := ast.OutputType(); !t.IsExactType(want) && !t.IsExactType(cel.DynType) {
+		return nil, fmt.Errorf("%q returns %s, want %s", expr, t, want)
+	}
+	return env.Program(ast)
+}
+
+func (c *checker) Check(ctx context.Context, appName, version string) ([]Violation, error) {
+	res, err := c.renderer.Render(ctx, appName, version)
+	if err != nil {
+		return nil, err
+	}
+	return c.Evaluate(appName, res.Version, res.Objects())
+}
+
+func (c *checker) Evaluate(appName, version string, objs []*unstructured.Unstructured) ([]Violation, error) {
+	versionPath, err := c.catalog.PathToApp(appName, version)
+	if err != nil {
+		return nil, err
+	}
+	exceptions, err := LoadExceptions(filepath.Dir(versionPath))
+	if err != nil {
+		return nil, err
+	}
+	if exceptions != nil {
+		for _, e := range exceptions.Exceptions {
+			if _, ok := c.compiled[e.Policy]; !ok {
+				return nil, fmt.Errorf("%s %s: unknown policy %s", appName, ExceptionsFile, e.Policy)
+			}
+		}
+	}
+
+	var out []Violation
+	for _, obj := range objs {
+		podSpec := podSpecOf(obj)
+		vars := map[string]interface{}{
+			"object":     obj.Object,
+			"podSpec":    podSpec,
+			"containers": containersOf(podSpec),
+		}
+		for _, p := range c.policies.Policies {
+			if !matchesKind(p, obj) || excepted(exceptions, p.Name, obj) {
+				continue
+			}
+			for _, v := range c.compiled[p.Name] {
+				msg, ok := evaluate(v, vars)
+				if ok {
+					continue
+				}
+				out = append(out, Violation{
+					App: appName, Version: version, Policy: p.Name,
+					Kind: obj.GetKind(), Namespace: obj.GetNamespace(), Name: obj.GetName(),
+					Message: msg,
+				})
+			}
+		}
+	}
+	return out, nil
+}
+
+// evaluate returns true if the validation passes, otherwise the failure message.
+func evaluate(v compiledValidation, vars map[string]interface{}) (string, bool) {
+	val, _, err := v.expr.Eval(vars)
+	if err != nil {
+		return fmt.Sprintf("evaluating %q: %v", v.Expression, err), false
+	}
+	if pass, ok := val.Value().(bool); ok && pass {
+		return "", true
+	}
+	if v.message != nil {
+		if m, _, err := v.message.Eval(vars); err == nil {
+			if s, ok := m.Value().(string); ok && s != "" {
+				return s, false
+			}
+		}
+	}
+	if v.Message != "" {
+		return v.Message, false
+	}
+	return fmt.Sprintf("failed %q", v.Expression), false
+}
+
+func matchesKind(p Policy, obj *unstructured.Unstructured) bool {
+	if len(p.Kinds) == 0 {
+		return true
+	}
+	for _, k := range p.Kinds {
+		if k == obj.GetKind() {
+			return true
+		}
+	}
+	return false
+}
+
+func excepted(ex *Exceptions, policy string, obj *unstructured.Unstructured) bool {
+	if ex == nil {
+		return false
+	}
+	for _, e := range ex.Exceptions {
+		if e.Policy == policy && e.matches(obj) {
+			return true
+		}
+	}
+	return false
+}
+
+// podSpecOf returns the pod spec of a workload object, or an empty map.
+func podSpecOf(obj *unstructured.Unstructured) map[string]interface{} {
+	var fields []string
+	switch obj.GetKind() {
+	case "Pod":
+		fields = []string{"spec"}
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job":
+		fields = []string{"spec", "template", "spec"}
+	case "CronJob":
+		fields = []string{"spec", "jobTemplate", "spec", "template", "spec"}
+	default:
+		return map[string]interface{}{}
+	}
+	spec, ok, _ := unstructured.NestedMap(obj.Object, fields...)
+	if !ok {
+		return map[string]interface{}{}
+	}
+	return spec
+}
+
+func containersOf(podSpec map[string]interface{}) []interface{} {
+	out := []interface{}{}
+	for _, field := range []string{"initContainers", "containers", "ephemeralContainers"} {
+		if cs, ok := podSpec[field].([]interface{}); ok {
+			out = append(out, cs...)
+		}
+	}
+	return out
+}
@@ -0,0 +1,180 @@
+package policy
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	catalogapptests "github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests"
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/chartcache"
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/render"
+)
+
+func TestPolicy(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Policy Suite")
+}
+
+func object(manifest string) *unstructured.Unstructured {
+	var m map[string]interface{}
+	Expect(yaml.Unmarshal([]byte(manifest), &m)).To(Succeed())
+	return &unstructured.Unstructured{Object: m}
+}
+
+const compliant = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: demo
+spec:
+  template:
+    spec:
+      containers:
+        - name: web
+          image: ghcr.io/example/web:1.2.3
+          resources:
+            requests: {cpu: 10m, memory: 16Mi}
+`
+
+const offending = `apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: agent
+  namespace: demo
+spec:
+  template:
+    spec:
+      initContainers:
+        - name: init
+          image: busybox@sha256:0123
+          resources:
+            requests: {cpu: 10m, memory: 16Mi}
+      containers:
+        - name: agent
+          image: localhost:5000/example/agent
+          securityContext:
+            privileged: true
+        - name: sidecar
+          image: example/sidecar:latest
+          resources:
+            requests: {cpu: 10m}
+`
+
+var _ = Describe("Checker", func() {
+	var (
+		appDir  string
+		checker Checker
+	)
+
+	BeforeEach(func() {
+		appsDir := GinkgoT().TempDir()
+		appDir = filepath.Join(appsDir, "demo")
+		Expect(os.MkdirAll(filepath.Join(appDir, "1.0.0"), 0o755)).To(Succeed())
+		cat, err := catalogapptests.NewCatalogAt(appsDir)
+		Expect(err).ToNot(HaveOccurred())
+		checker, err = NewChecker(cat, nil)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("passes compliant workloads and ignores other kinds", func() {
+		objs := []*unstructured.Unstructured{object(compliant), object("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm\n")}
+		Expect(checker.Evaluate("demo", "1.0.0", objs)).To(BeEmpty())
+	})
+
+	It("reports each violated policy with the offending containers", func() {
+		violations, err := checker.Evaluate("demo", "1.0.0", []*unstructured.Unstructured{object(offending)})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(violations).To(HaveLen(3))
+		Expect(violations[0].Policy).To(Equal("no-latest-tag"))
+		Expect(violations[0].Message).To(Equal("images without a pinned tag: localhost:5000/example/agent, example/sidecar:latest"))
+		Expect(violations[1].Policy).To(Equal("resource-requests"))
+		Expect(violations[1].Message).To(Equal("containers without cpu/memory requests: agent, sidecar"))
+		Expect(violations[2].Policy).To(Equal("no-privileged"))
+		Expect(violations[2].String()).To(Equal("demo/1.0.0: no-privileged DaemonSet/demo/agent: privileged containers: agent"))
+	})
+
+	It("applies per-app exceptions", func() {
+		Expect(os.WriteFile(filepath.Join(appDir, ExceptionsFile), []byte(`exceptions:
+  - policy: no-privileged
+    objects: [DaemonSet/agent]
+    reason: the agent loads eBPF programs
+  - policy: resource-requests
+    objects: [Deployment/other]
+    reason: not this object
+`), 0o644)).To(Succeed())
+		violations, err := checker.Evaluate("demo", "1.0.0", []*unstructured.Unstructured{object(offending)})
+		Expect(err).ToNot(HaveOccurred())
+		var policies []string
+		for _, v := range violations {
+			policies = append(policies, v.Policy)
+		}
+		Expect(policies).To(Equal([]string{"no-latest-tag", "resource-requests"}))
+	})
+
+	It("rejects exceptions without a reason or for unknown policies", func() {
+		path := filepath.Join(appDir, ExceptionsFile)
+		Expect(os.WriteFile(path, []byte("exceptions:\n  - policy: no-privileged\n"), 0o644)).To(Succeed())
+		_, err := checker.Evaluate("demo", "1.0.0", nil)
+		Expect(err).To(MatchError(ContainSubstring("reason")))
+
+		Expect(os.WriteFile(path, []byte("exceptions:\n  - policy: nope\n    reason: x\n"), 0o644)).To(Succeed())
+		_, err = checker.Evaluate("demo", "1.0.0", nil)
+		Expect(err).To(MatchError(ContainSubstring("unknown policy nope")))
+	})
+
+	It("rejects policies that do not compile to a boolean", func() {
+		cat, err := catalogapptests.NewCatalogAt(filepath.Dir(appDir))
+		Expect(err).ToNot(HaveOccurred())
+		_, err = NewChecker(cat, nil, WithPolicySet(&PolicySet{Policies: []Policy{{
+			Name: "bad", Validations: []Validation{{Expression: "'not a bool'"}},
+		}}}))
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+// Policies against the real catalog: just policies [app], or go test ./policy/ -ginkgo.label-filter=catalog.
+// The sweep runs only when a label filter selects it, so plain go test ./... stays a unit run; versions whose
+// chart is not in the chart cache are skipped (fill it with go run ./cmd/check-policy --all --pull).
+var _ = Describe("Catalog policies", Label("catalog"), func() {
+	catalog, err := catalogapptests.DefaultCatalog()
+	if err != nil {
+		Fail("discovery failed: " + err.Error())
+	}
+	apps, err := catalog.Apps()
+	if err != nil {
+		Fail("discovery failed: " + err.Error())
+	}
+
+	var checker Checker
+	BeforeEach(func() {
+		if GinkgoLabelFilter() == "" {
+			Skip("catalog policy sweep: select it with -ginkgo.label-filter=catalog (just policies)")
+		}
+		cache, err := chartcache.NewCache("")
+		Expect(err).ToNot(HaveOccurred())
+		checker, err = NewChecker(catalog, render.NewRenderer(catalog, cache))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	for _, app := range apps {
+		for _, version := range app.Versions {
+			It(app.Name+"/"+version+" should satisfy the catalog policies", Label("appname", app.Name), func(ctx SpecContext) {
+				violations, err := checker.Check(ctx, app.Name, version)
+				if errors.Is(err, chartcache.ErrNotCached) {
+					Skip(err.Error())
+				}
+				Expect(err).ToNot(HaveOccurred())
+				for _, v := range violations {
+					AddReportEntry(v.Policy, v.String())
+				}
+				Expect(violations).To(BeEmpty())
+			})
+		}
+	}
+})
@@ -0,0 +1,143 @@
+package catalogapptests
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	fluxhelmv2 "github.com/fluxcd/helm-controller/api/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrlClient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/framework"
+)
+
+// helmReleaseSecretFormat is the name of the Secret Helm stores a release version in (name, version).
+const helmReleaseSecretFormat = "sh.helm.release.v1.%s.v%d"
+
+// InstallOnWorkload renders the app version's helmrelease/ kustomization (as InstallModeDirect does) into the
+// namespace named after the workload cluster on this cluster, points its HelmReleases at the workload through
+// the kubeconfig Secret from RegisterWorkloadCluster (spec.kubeConfig) and applies it, so this cluster's Flux
+// installs the release on the workload. The release lands in the namespaces a direct install on the workload
+// would use. Returns the namespace holding the HelmReleases on this cluster.
+func (c *clusterImpl) InstallOnWorkload(app *CatalogApp, workload NKPWorkloadCluster) (string, error) {
+	w, ok := workload.(PeerCluster)
+	if !ok {
+		return "", fmt.Errorf("InstallOnWorkload: %T does not implement PeerCluster", workload)
+	}
+	cat := c.catalog
+	if cat == nil {
+		var err error
+		cat, err = DefaultCatalog()
+		if err != nil {
+			return "", err
+		}
+	}
+	appPath, err := cat.PathToApp(app.AppName, app.VersionToInstall)
+	if err != nil {
+		return "", err
+	}
+	if app.preloadImages() {
+		images, err := AppImages(appPath)
+		if err != nil {
+			return "", err
+		}
+		if len(images) > 0 {
+			if err := workload.PreloadImages(workload.Ctx(), images...); err != nil {
+				return "", fmt.Errorf("preload images of %s: %w", app.AppName, err)
+			}
+		}
+	}
+
+	namespace := w.Name()
+	ns := &corev1.Namespace{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+		ObjectMeta: metav1.ObjectMeta{Name: namespace},
+	}
+	if err := c.client.Patch(c.ctx, ns, ctrlClient.Apply, ctrlClient.ForceOwnership, ctrlClient.FieldOwner("catalog-apptests")); err != nil {
+		return "", fmt.Errorf("apply Namespace %s: %w", namespace, err)
+	}
+	secretName, err := c.registerWorkloadCluster(c.ctx, workload, namespace)
+	if err != nil {
+		return "", err
+	}
+	objs, err := framework.BuildKustomization(filepath.Join(appPath, "helmrelease"), map[string]string{
+		"releaseNamespace": namespace,
+		"releaseName":      app.AppName,
+	})
+	if err != nil {
+		return "", err
+	}
+	for _, obj := range objs {
+		if obj.GetKind() == fluxhelmv2.HelmReleaseKind {
+			if err := targetWorkload(obj, namespace, secretName); err != nil {
+				return "", err
+			}
+		}
+		if err := c.client.Patch(c.ctx, obj, ctrlClient.Apply, ctrlClient.ForceOwnership, ctrlClient.FieldOwner("catalog-apptests")); err != nil {
+			return "", fmt.Errorf("apply %s %s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+	}
+	return namespace, nil
+}
+
+// targetWorkload points a HelmRelease rendered into namespace at the cluster in the kubeconfig Secret
+// secretName. A targetNamespace of namespace (or none) becomes DefaultNamespace, and the Helm storage moves to
+// the targetNamespace: the workload has no namespace named after itself.
+func targetWorkload(hr *unstructured.Unstructured, namespace, secretName string) error {
+	target, _, err := unstructured.NestedString(hr.Object, "spec", "targetNamespace")
+	if err != nil {
+		return fmt.Errorf("HelmRelease %s: %w", hr.GetName(), err)
+	}
+	if target == "" || target == namespace {
+		target = DefaultNamespace
+	}
+	if err := unstructured.SetNestedField(hr.Object, target, "spec", "targetNamespace"); err != nil {
+		return err
+	}
+	if storage, _, _ := unstructured.NestedString(hr.Object, "spec", "storageNamespace"); storage == "" || storage == namespace {
+		if err := unstructured.SetNestedField(hr.Object, target, "spec", "storageNamespace"); err != nil {
+			return err
+		}
+	}
+	return unstructured.SetNestedMap(hr.Object, map[string]interface{}{
+		"name": secretName,
+		"key":  KubeconfigSecretKey,
+	}, "spec", "kubeConfig", "secretRef")
+}
+
+// RemoteReleaseDeployed returns nil when the HelmRelease namespace/name on the management cluster is Ready and
+// its latest release is stored as deployed on the workload cluster (the Helm release Secret in the storage
+// namespace), i.e. the release Flux on mgmt delivered has landed on the workload.
+func RemoteReleaseDeployed(ctx context.Context, mgmt, workload ctrlClient.Client, namespace, name string) error {
+	hr := &fluxhelmv2.HelmRelease{}
+	if err := mgmt.Get(ctx, ctrlClient.ObjectKey{Namespace: namespace, Name: name}, hr); err != nil {
+		return fmt.Errorf("HelmRelease %s/%s: %w", namespace, name, err)
+	}
+	ready := readyCondition(hr.Status.Conditions)
+	if ready == nil {
+		return fmt.Errorf("HelmRelease %s/%s has no Ready condition yet", namespace, name)
+	}
+	if ready.Status != metav1.ConditionTrue || ready.ObservedGeneration < hr.Generation {
+		return fmt.Errorf("HelmRelease %s/%s not ready: %s: %s", namespace, name, ready.Reason, ready.Message)
+	}
+	latest := hr.Status.History.Latest()
+	if latest == nil {
+		return fmt.Errorf("HelmRelease %s/%s has no release history yet", namespace, name)
+	}
+	storage := hr.Status.StorageNamespace
+	if storage == "" {
+		storage = latest.Namespace
+	}
+	secret := &corev1.Secret{}
+	key := ctrlClient.ObjectKey{Namespace: storage, Name: fmt.Sprintf(helmReleaseSecretFormat, latest.Name, latest.Version)}
+	if err := workload.Get(ctx, key, secret); err != nil {
+		return fmt.Errorf("release %s/%s v%d on workload: %w", latest.Namespace, latest.Name, latest.Version, err)
+	}
+	if status := secret.Labels["status"]; status != "deployed" {
+		return fmt.Errorf("release %s/%s v%d on workload is %q, not deployed", latest.Namespace, latest.Name, latest.Version, status)
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+// Package render renders catalog app versions offline: the helmrelease kustomization is built, each
+// HelmRelease's chart is loaded from the chart cache, its values are composed from valuesFrom/values like
+// helm-controller, and the chart is templated client-side (helm install --dry-run, CRDs included).
+package render
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	catalogapptests "github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests"
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/chartcache"
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/framework"
+)
+
+// Release is one rendered HelmRelease.
+type Release struct {
+	// HelmRelease is the name of the HelmRelease object.
+	HelmRelease string
+	// Name and Namespace are the Helm release name and namespace helm-controller would use.
+	Name      string
+	Namespace string
+	Chart     *catalogapptests.ChartRef
+	// Values are the composed values passed to the chart.
+	Values map[string]interface{}
+	// Objects are the manifests rendered by the chart (CRDs first, then templates and hooks).
+	Objects []*unstructured.Unstructured
+}
+
+// Result is the offline render of applications/<app>/<version>.
+type Result struct {
+	App     string
+	Version string
+	// Kustomization holds the objects of the helmrelease kustomization (sources, HelmReleases, ConfigMaps, ...).
+	Kustomization []*unstructured.Unstructured
+	Releases      []Release
+}
+
+// Images returns the container images of the workloads in Objects(): the app version's image inventory
+// (catalogapptests.ImagesFile).
+func (r *Result) Images() []string {
+	return framework.ContainerImages(r.Objects())
+}
+
+// Objects returns the kustomization objects other than Flux sources and HelmReleases, followed by every
+// release's rendered objects: what ends up on the cluster once Flux has reconciled the app.
+func (r *Result) Objects() []*unstructured.Unstructured {
+	var out []*unstructured.Unstructured
+	for _, o := range r.Kustomization {
+		if isFluxObject(o) {
+			continue
+		}
+		out = append(out, o)
+	}
+	for _, rel := range r.Releases {
+		out = append(out, rel.Objects...)
+	}
+	return out
+}
+
+func isFluxObject(o *unstructured.Unstructured) bool {
+	return strings.HasSuffix(o.GroupVersionKind().Group, ".toolkit.fluxcd.io")
+}
+
+// Renderer renders catalog app versions without a cluster.
+type Renderer interface {
+	// Render renders applications/<app>/<version> (empty version = latest).
+	Render(ctx context.Context, appName, version string) (*Result, error)
+}
+
+// Ensure renderer implements Renderer at compile time.
+var _ Renderer = (*renderer)(nil)
+
+type renderer struct {
+	catalog     catalogapptests.Catalog
+	charts      chartcache.Cache
+	namespace   string
+	kubeVersion string
+	apiVersions []string
+}
+
+// Option configures a Renderer.
+type Option func(*renderer)
+
+// WithNamespace sets ${releaseNamespace} (default: DefaultNamespace).
+func WithNamespace(namespace string) Option {
+	return func(r *renderer) { r.namespace = namespace }
+}
+
+// WithKubeVersion sets .Capabilities.KubeVersion (e.g. "v1.33.1"; default: Helm's built-in version).
+func WithKubeVersion(version string) Option {
+	return func(r *renderer) { r.kubeVersion = version }
+}
+
+// WithAPIVersions adds group/versions (or group/version/Kind) to .Capabilities.APIVersions, for charts
+// that only render some templates when an API is available (e.g. "monitoring.coreos.com/v1").
+func WithAPIVersions(apiVersions ...string) Option {
+	return func(r *renderer) { r.apiVersions = append(r.apiVersions, apiVersions...) }
+}
+
+// NewRenderer returns a Renderer loading charts from the given cache.
+func NewRenderer(cat catalogapptests.Catalog, charts chartcache.Cache, opts ...Option) Renderer {
+	r := &renderer{catalog: cat, charts: charts, namespace: catalogapptests.DefaultNamespace}
+	for _, o := range opts {
+		o(r)
+	}
+	return r
+}
+
+func (r *renderer) Render(ctx context.Context, appName, version string) (*Result, error) {
+	versionPath, err := r.catalog.PathToApp(appName, version)
+	if err != nil {
+		return nil, err
+	}
+	objs, err := framework.BuildKustomization(filepath.Join(versionPath, "helmrelease"), map[string]string{
+		"releaseNamespace": r.namespace,
+		"releaseName":      appName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{App: appName, Version: filepath.Base(versionPath), Kustomization: objs}
+	for _, hr := range objs {
+		if hr.GetKind() != "HelmRelease" {
+			continue
+		}
+		rel, err := r.renderRelease(ctx, hr, objs)
+		if err != nil {
+			return nil, fmt.Errorf("%s/%s: %w", appName, res.Version, err)
+		}
+		res.Releases = append(res.Releases, *rel)
+	}
+	return res, nil
+}
+
+func (r *renderer) renderRelease(ctx context.Context, hr *unstructured.Unstructured, objs []*unstructured.Unstructured) (*Release, error) {
+	ref, err := chartcache.RefFromHelmRelease(hr, objs)
+	if err != nil {
+		return nil, err
+	}
+	chrt, err := r.charts.Get(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	values, err := Values(hr, objs)
+	if err != nil {
+		return nil, err
+	}
+	rel := &Release{
+		HelmRelease: hr.GetName(),
+		Name:        ReleaseName(hr),
+		Namespace:   ReleaseNamespace(hr),
+		Chart:       ref,
+		Values:      values,
+	}
+
+	install := action.NewInstall(&action.Configuration{Log: func(string, ...interface{}) {}})
+	install.DryRun = true
+	install.ClientOnly = true
+	install.Replace = true
+	install.ReleaseName = rel.Name
+	install.Namespace = rel.Namespace
+	crds, _, _ := unstructured.NestedString(hr.Object, "spec", "install", "crds")
+	install.IncludeCRDs = crds != "Skip"
+	install.DisableHooks, _, _ = unstructured.NestedBool(hr.Object, "spec", "install", "disableHooks")
+	install.APIVersions = r.apiVersions
+	if r.kubeVersion != "" {
+		kv, err := chartutil.ParseKubeVersion(r.kubeVersion)
+		if err != nil {
+			return nil, err
+		}
+		install.KubeVersion = kv
+	}
+	out, err := install.RunWithContext(ctx, chrt, values)
+	if err != nil {
+		return nil, fmt.Errorf("HelmRelease %s: render chart %s: %w", hr.GetName(), chrt.Name(), err)
+	}
+	if rel.Objects, err = decodeManifest(out.Manifest); err != nil {
+		return nil, fmt.Errorf("HelmRelease %s: %w", hr.GetName(), err)
+	}
+	for _, h := range out.Hooks {
+		if isTestHook(h) {
+			continue
+		}
+		hookObjs, err := decodeManifest(h.Manifest)
+		if err != nil {
+			return nil, fmt.Errorf("HelmRelease %s: hook %s: %w", hr.GetName(), h.Path, err)
+		}
+		rel.Objects = append(rel.Objects, hookObjs...)
+	}
+	return rel, nil
+}
+
+// isTestHook reports whether h only runs on helm test (helm-controller does not install those by default).
+func isTestHook(h *release.Hook) bool {
+	for _, e := range h.Events {
+		if e != release.HookTest {
+			return false
+		}
+	}
+	return len(h.Events) > 0
+}
+
+func decodeManifest(manifest string) ([]*unstructured.Unstructured, error) {
+	docs := releaseutil.SplitManifests(manifest)
+	keys := make([]string, 0, len(docs))
+	for k := range docs {
+		keys = append(keys, k)
+	}
+	sort.Sort(releaseutil.BySplitManifestsOrder(keys))
+	var out []*unstructured.Unstructured
+	for _, k := range keys {
+		var m map[string]interface{}
+		if err := yaml.Unmarshal([]byte(docs[k]), &m); err != nil {
+			return nil, fmt.Errorf("decode rendered manifest: %w", err)
+		}
+		if len(m) == 0 {
+			continue
+		}
+		out = append(out, &unstructured.Unstructured{Object: m})
+	}
+	return out, nil
+}
+
+// ReleaseName returns the Helm release name helm-controller uses for hr: spec.releaseName, else
+// [<targetNamespace>-]<name>, shortened to 53 characters with a hash suffix.
+func ReleaseName(hr *unstructured.Unstructured) string {
+	name, _, _ := unstructured.NestedString(hr.Object, "spec", "releaseName")
+	if name == "" {
+		name = hr.GetName()
+		if ns, _, _ := unstructured.NestedString(hr.Object, "spec", "targetNamespace"); ns != "" {
+			name = ns + "-" + name
+		}
+	}
+	const maxLength, hashLength = 53, 12
+	if len(name) <= maxLength {
+		return name
+	}
+	sum := fmt.Sprintf("%x", sha256.Sum256([]byte(name)))
+	return name[:maxLength-hashLength-1] + "-" + sum[:hashLength]
+}
+
+// ReleaseNamespace returns spec.targetNamespace, else the HelmRelease namespace.
+func ReleaseNamespace(hr *unstructured.Unstructured) string {
+	if ns, _, _ := unstructured.NestedString(hr.Object, "spec", "targetNamespace"); ns != "" {
+		return ns
+	}
+	return hr.GetNamespace()
+}
+
+// WriteYAML writes objs as a multi-document YAML stream.
+func WriteYAML(w io.Writer, objs []*unstructured.Unstructured) error {
+	for _, o := range objs {
+		b, err := yaml.Marshal(o.Object)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "---\n%s", b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
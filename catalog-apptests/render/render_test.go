@@ -0,0 +1,157 @@
+package render
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	catalogapptests "github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests"
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/chartcache"
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/internal/testutil"
+)
+
+func TestRender(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Render Suite")
+}
+
+const configMapTemplate = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .Release.Name }}-settings
+  namespace: {{ .Release.Namespace }}
+data:
+  replicas: {{ .Values.replicaCount | quote }}
+  tag: {{ .Values.image.tag | quote }}
+  token: {{ .Values.auth.token | quote }}
+  mode: {{ .Values.mode | quote }}
+`
+
+const deploymentTemplate = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .Release.Name }}
+spec:
+  template:
+    spec:
+      initContainers:
+        - name: init
+          image: busybox:1.36
+      containers:
+        - name: app
+          image: "{{ .Values.image.repository }}:{{ .Values.image.tag }}"
+`
+
+const crd = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+`
+
+// cacheChart saves a chart where the cache expects registry.example.com/charts/demo:1.0.0.
+func cacheChart(cacheDir string) {
+	testutil.CacheChart(cacheDir, &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "demo", Version: "1.0.0"},
+		Raw: []*chart.File{{Name: chartutil.ValuesfileName,
+			Data: []byte("replicaCount: 1\nimage:\n  repository: demo\n  tag: latest\nauth:\n  token: \"\"\nmode: default\n")}},
+		Templates: []*chart.File{
+			{Name: "templates/cm.yaml", Data: []byte(configMapTemplate)},
+			{Name: "templates/deployment.yaml", Data: []byte(deploymentTemplate)},
+		},
+		Files: []*chart.File{{Name: "crds/widgets.yaml", Data: []byte(crd)}},
+	})
+}
+
+func writeApp(appsDir string) {
+	testutil.WriteApp(appsDir, "demo", "1.0.0", testutil.HelmRelease("demo", "demo", "1.0.0", `targetNamespace: apps
+valuesFrom:
+  - kind: ConfigMap
+    name: ${releaseName}-config-defaults
+  - kind: Secret
+    name: ${releaseName}-auth
+    valuesKey: token
+    targetPath: auth.token
+  - kind: ConfigMap
+    name: ${releaseName}-config-overrides
+    optional: true
+values:
+  mode: inline
+`), testutil.DefaultsConfigMap("replicaCount: 2\nimage:\n  tag: v1\n")+`---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: ${releaseName}-auth
+  namespace: ${releaseNamespace}
+data:
+  token: `+base64.StdEncoding.EncodeToString([]byte("s3cr3t,x"))+"\n")
+}
+
+var _ = Describe("Renderer", func() {
+	var renderer Renderer
+
+	BeforeEach(func() {
+		appsDir := GinkgoT().TempDir()
+		cacheDir := GinkgoT().TempDir()
+		writeApp(appsDir)
+		cacheChart(cacheDir)
+		cat, err := catalogapptests.NewCatalogAt(appsDir)
+		Expect(err).ToNot(HaveOccurred())
+		cache, err := chartcache.NewCache(cacheDir)
+		Expect(err).ToNot(HaveOccurred())
+		renderer = NewRenderer(cat, cache, WithNamespace("flux-apps"))
+	})
+
+	It("renders the chart with values merged like helm-controller", func() {
+		res, err := renderer.Render(context.Background(), "demo", "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.Version).To(Equal("1.0.0"))
+		Expect(res.Releases).To(HaveLen(1))
+		rel := res.Releases[0]
+		Expect(rel.Name).To(Equal("apps-demo"))
+		Expect(rel.Namespace).To(Equal("apps"))
+
+		Expect(rel.Objects).To(HaveLen(3))
+		Expect(rel.Objects[0].GetKind()).To(Equal("CustomResourceDefinition"))
+		cm := rel.Objects[1]
+		Expect(cm.GetName()).To(Equal("apps-demo-settings"))
+		Expect(cm.GetNamespace()).To(Equal("apps"))
+		Expect(cm.Object["data"]).To(Equal(map[string]interface{}{
+			"replicas": "2",
+			"tag":      "v1",
+			"token":    "s3cr3t,x",
+			"mode":     "inline",
+		}))
+	})
+
+	It("returns cluster objects without Flux resources", func() {
+		res, err := renderer.Render(context.Background(), "demo", "1.0.0")
+		Expect(err).ToNot(HaveOccurred())
+		var kinds []string
+		for _, o := range res.Objects() {
+			kinds = append(kinds, o.GetKind())
+		}
+		Expect(kinds).To(Equal([]string{"ConfigMap", "Secret", "CustomResourceDefinition", "ConfigMap", "Deployment"}))
+	})
+
+	It("lists the workload images", func() {
+		res, err := renderer.Render(context.Background(), "demo", "1.0.0")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.Images()).To(Equal([]string{"busybox:1.36", "demo:v1"}))
+	})
+})
+
+var _ = Describe("ReleaseName", func() {
+	It("shortens long names like helm-controller", func() {
+		hr := map[string]interface{}{"metadata": map[string]interface{}{"name": "a-very-long-helmrelease-name-that-exceeds-the-helm-limit"},
+			"spec": map[string]interface{}{"targetNamespace": "namespace"}}
+		name := ReleaseName(&unstructured.Unstructured{Object: hr})
+		Expect(name).To(HaveLen(53))
+		Expect(name).To(HavePrefix("namespace-a-very-long-helmrelease-name-"))
+	})
+})
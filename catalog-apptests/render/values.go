@@ -0,0 +1,145 @@
+package render
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/strvals"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Values composes the values of a HelmRelease the way helm-controller does: spec.valuesFrom entries in order
+// (deep-merged, or set at targetPath), then spec.values on top. ConfigMaps and Secrets are looked up in objs
+// (the rendered helmrelease kustomization); missing ones are an error unless the reference is optional.
+func Values(hr *unstructured.Unstructured, objs []*unstructured.Unstructured) (map[string]interface{}, error) {
+	result := map[string]interface{}{}
+	refs, _, err := unstructured.NestedSlice(hr.Object, "spec", "valuesFrom")
+	if err != nil {
+		return nil, fmt.Errorf("HelmRelease %s: spec.valuesFrom: %w", hr.GetName(), err)
+	}
+	for _, r := range refs {
+		ref, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		kind, _ := ref["kind"].(string)
+		name, _ := ref["name"].(string)
+		key, _ := ref["valuesKey"].(string)
+		targetPath, _ := ref["targetPath"].(string)
+		optional, _ := ref["optional"].(bool)
+		if key == "" {
+			key = chartutil.ValuesfileName
+		}
+
+		data, found, err := lookupData(kind, name, hr.GetNamespace(), objs)
+		if err != nil {
+			return nil, fmt.Errorf("HelmRelease %s: %w", hr.GetName(), err)
+		}
+		if !found {
+			if optional {
+				continue
+			}
+			return nil, fmt.Errorf("HelmRelease %s: could not find %s '%s/%s'", hr.GetName(), kind, hr.GetNamespace(), name)
+		}
+		value, ok := data[key]
+		if !ok {
+			if optional {
+				continue
+			}
+			return nil, fmt.Errorf("HelmRelease %s: missing key '%s' in %s '%s/%s'", hr.GetName(), key, kind, hr.GetNamespace(), name)
+		}
+
+		if targetPath != "" {
+			if err := replacePathValue(result, targetPath, value); err != nil {
+				return nil, fmt.Errorf("HelmRelease %s: unable to merge value from key '%s' in %s '%s/%s' into target path '%s': %w",
+					hr.GetName(), key, kind, hr.GetNamespace(), name, targetPath, err)
+			}
+			continue
+		}
+		values, err := chartutil.ReadValues([]byte(value))
+		if err != nil {
+			return nil, fmt.Errorf("HelmRelease %s: unable to read values from key '%s' in %s '%s/%s': %w",
+				hr.GetName(), key, kind, hr.GetNamespace(), name, err)
+		}
+		result = mergeMaps(result, values)
+	}
+
+	inline, _, err := unstructured.NestedMap(hr.Object, "spec", "values")
+	if err != nil {
+		return nil, fmt.Errorf("HelmRelease %s: spec.values: %w", hr.GetName(), err)
+	}
+	return mergeMaps(result, inline), nil
+}
+
+// lookupData returns the decoded data of the ConfigMap or Secret name in namespace.
+func lookupData(kind, name, namespace string, objs []*unstructured.Unstructured) (map[string]string, bool, error) {
+	for _, o := range objs {
+		if o.GetKind() != kind || o.GetName() != name || o.GetNamespace() != namespace {
+			continue
+		}
+		switch kind {
+		case "ConfigMap":
+			data, _, _ := unstructured.NestedStringMap(o.Object, "data")
+			return data, true, nil
+		case "Secret":
+			data := map[string]string{}
+			encoded, _, _ := unstructured.NestedStringMap(o.Object, "data")
+			for k, v := range encoded {
+				b, err := base64.StdEncoding.DecodeString(v)
+				if err != nil {
+					return nil, false, fmt.Errorf("Secret '%s/%s' key %s: %w", namespace, name, k, err)
+				}
+				data[k] = string(b)
+			}
+			plain, _, _ := unstructured.NestedStringMap(o.Object, "stringData")
+			for k, v := range plain {
+				data[k] = v
+			}
+			return data, true, nil
+		default:
+			return nil, false, fmt.Errorf("unsupported valuesFrom kind '%s'", kind)
+		}
+	}
+	return nil, false, nil
+}
+
+// replacePathValue sets value at path in values, parsed like a Helm --set flag the way helm-controller does:
+// quoted values are set as strings, otherwise commas and a leading "=" are escaped.
+func replacePathValue(values map[string]interface{}, path, value string) error {
+	const (
+		singleQuote = "'"
+		doubleQuote = `"`
+	)
+	isSingleQuoted := strings.HasPrefix(value, singleQuote) && strings.HasSuffix(value, singleQuote)
+	isDoubleQuoted := strings.HasPrefix(value, doubleQuote) && strings.HasSuffix(value, doubleQuote)
+	if isSingleQuoted || isDoubleQuoted {
+		value = strings.Trim(value, singleQuote+doubleQuote)
+		value = path + "=" + value
+		return strvals.ParseIntoString(value, values)
+	}
+	value = strings.ReplaceAll(value, ",", `\,`)
+	if strings.HasPrefix(value, "=") {
+		value = `\` + value
+	}
+	return strvals.ParseInto(path+"="+value, values)
+}
+
+// mergeMaps deep-merges b into a copy of a; values from b win, nested maps are merged.
+func mergeMaps(a, b map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(a))
+	for k, v := range a {
+		out[k] = v
+	}
+	for k, v := range b {
+		if vm, ok := v.(map[string]interface{}); ok {
+			if am, ok := out[k].(map[string]interface{}); ok {
+				out[k] = mergeMaps(am, vm)
+				continue
+			}
+		}
+		out[k] = v
+	}
+	return out
+}
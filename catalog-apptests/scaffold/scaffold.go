@@ -0,0 +1,288 @@
+// Package scaffold creates a new catalog version directory from the latest existing one
+// (Go counterpart of the version-bump path of scripts/add-application.sh).
+package scaffold
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	catalogapptests "github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests"
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/chartcache"
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/framework"
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/render"
+)
+
+// Result describes a scaffolded version.
+type Result struct {
+	App         string
+	FromVersion string
+	Version     string
+	Path        string   // absolute path to applications/<app>/<version>
+	Rewritten   []string // files (relative to Path) whose content changed
+	Rendered    bool     // the new chart version was rendered with Helm (false when it is not in the chart cache)
+}
+
+// Scaffolder creates new app versions.
+type Scaffolder interface {
+	// NewVersion copies applications/<app>/<from> to applications/<app>/<version>, rewrites the chart
+	// reference and version strings, updates metadata and runs the offline render check. When a chart
+	// cache is configured and holds the new chart version, the HelmReleases are also rendered with Helm.
+	NewVersion(ctx context.Context, appName, version string) (*Result, error)
+}
+
+// Ensure scaffolder implements Scaffolder at compile time.
+var _ Scaffolder = (*scaffolder)(nil)
+
+type scaffolder struct {
+	catalog     catalogapptests.Catalog
+	charts      chartcache.Cache
+	fromVersion string
+	skipRender  bool
+}
+
+// Option configures a Scaffolder.
+type Option func(*scaffolder)
+
+// WithFromVersion copies the given version instead of the latest one.
+func WithFromVersion(version string) Option {
+	return func(s *scaffolder) { s.fromVersion = version }
+}
+
+// WithoutRenderCheck skips the offline render check (the new directory is still written).
+func WithoutRenderCheck() Option {
+	return func(s *scaffolder) { s.skipRender = true }
+}
+
+// WithChartCache renders the new version with Helm from the given cache after the render check.
+// Charts missing from the cache (chartcache.ErrNotCached) leave Result.Rendered false.
+func WithChartCache(charts chartcache.Cache) Option {
+	return func(s *scaffolder) { s.charts = charts }
+}
+
+// NewScaffolder returns a Scaffolder over the given catalog.
+func NewScaffolder(cat catalogapptests.Catalog, opts ...Option) Scaffolder {
+	s := &scaffolder{catalog: cat}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+func (s *scaffolder) NewVersion(ctx context.Context, appName, version string) (*Result, error) {
+	if appName == "" || version == "" {
+		return nil, fmt.Errorf("app name and version are required")
+	}
+	from := s.fromVersion
+	if from == "" {
+		apps, err := s.catalog.Apps()
+		if err != nil {
+			return nil, err
+		}
+		for _, av := range apps {
+			if av.Name == appName {
+				from = catalogapptests.LatestVersion(av.Versions)
+			}
+		}
+		if from == "" {
+			return nil, fmt.Errorf("application %q not found in catalog", appName)
+		}
+	}
+	if from == version {
+		return nil, fmt.Errorf("%s %s already exists", appName, version)
+	}
+	src, err := s.catalog.PathToApp(appName, from)
+	if err != nil {
+		return nil, err
+	}
+	dst := filepath.Join(filepath.Dir(src), version)
+	if _, err := os.Stat(dst); err == nil {
+		return nil, fmt.Errorf("%s already exists", dst)
+	}
+
+	result := &Result{App: appName, FromVersion: from, Version: version, Path: dst}
+	if err := copyTree(src, dst); err != nil {
+		_ = os.RemoveAll(dst)
+		return nil, err
+	}
+	if err := s.rewrite(result); err != nil {
+		_ = os.RemoveAll(dst)
+		return nil, err
+	}
+	if !s.skipRender {
+		if err := RenderCheck(appName, dst, version); err != nil {
+			return result, fmt.Errorf("render check %s: %w", dst, err)
+		}
+		if s.charts != nil {
+			_, err := render.NewRenderer(s.catalog, s.charts).Render(ctx, appName, version)
+			switch {
+			case errors.Is(err, chartcache.ErrNotCached):
+			case err != nil:
+				return result, fmt.Errorf("helm render %s: %w", dst, err)
+			default:
+				result.Rendered = true
+			}
+		}
+	}
+	return result, nil
+}
+
+// rewrite updates the copied tree: version strings everywhere, then the chart reference in
+// helmrelease/helmrelease.yaml (which may not equal the directory version, e.g. a git-sha tag).
+func (s *scaffolder) rewrite(r *Result) error {
+	versionRe := versionPattern(r.FromVersion)
+	err := filepath.WalkDir(r.Path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		out := versionRe.ReplaceAll(b, []byte("${1}"+r.Version+"${2}"))
+		rel, _ := filepath.Rel(r.Path, path)
+		if filepath.ToSlash(rel) == "helmrelease/helmrelease.yaml" {
+			out = setChartVersion(out, r.Version)
+		}
+		if string(out) == string(b) {
+			return nil
+		}
+		r.Rewritten = append(r.Rewritten, rel)
+		return os.WriteFile(path, out, 0o644)
+	})
+	if err != nil {
+		return err
+	}
+	sort.Strings(r.Rewritten)
+	return checkMetadata(filepath.Join(r.Path, "metadata.yaml"))
+}
+
+// versionPattern matches old as a whole version token, optionally "v"-prefixed, but not as part of
+// 1.6.60 or 11.6.6. Groups 1 and 2 capture the surrounding characters to keep.
+func versionPattern(old string) *regexp.Regexp {
+	return regexp.MustCompile(`(?m)(^v?|[^0-9A-Za-z.]v?)` + regexp.QuoteMeta(old) + `([^0-9A-Za-z.]|\.[^0-9]|$)`)
+}
+
+var (
+	tagLine     = regexp.MustCompile(`(?m)^(\s+tag:\s*)("?)[^"\s#]+("?)`)
+	versionLine = regexp.MustCompile(`(?m)^(\s+version:\s*)("?)[^"\s#]+("?)`)
+)
+
+// setChartVersion sets OCIRepository spec.ref.tag and HelmRelease spec.chart.spec.version, keeping
+// the file's formatting (documents are edited line-wise rather than re-serialized).
+func setChartVersion(b []byte, version string) []byte {
+	docs := strings.Split(string(b), "\n---")
+	for i, doc := range docs {
+		var obj struct {
+			Kind string `json:"kind"`
+		}
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+			continue
+		}
+		switch obj.Kind {
+		case "OCIRepository":
+			docs[i] = tagLine.ReplaceAllString(doc, "${1}${2}"+version+"${3}")
+		case "HelmRelease":
+			// Only the first version: under spec.chart (chart.spec.version), not values or other fields.
+			start := strings.Index(doc, "\n  chart:")
+			if start < 0 {
+				continue
+			}
+			if m := versionLine.FindStringSubmatchIndex(doc[start:]); m != nil {
+				line := versionLine.ReplaceAllString(doc[start+m[0]:start+m[1]], "${1}${2}"+version+"${3}")
+				docs[i] = doc[:start+m[0]] + line + doc[start+m[1]:]
+			}
+		}
+	}
+	return []byte(strings.Join(docs, "\n---"))
+}
+
+// checkMetadata ensures metadata.yaml still parses and keeps the fields the catalog requires.
+func checkMetadata(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var md struct {
+		Schema      string `json:"schema"`
+		DisplayName string `json:"displayName"`
+	}
+	if err := yaml.Unmarshal(b, &md); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	if md.Schema == "" || md.DisplayName == "" {
+		return fmt.Errorf("%s: schema and displayName are required", path)
+	}
+	return nil
+}
+
+// RenderCheck builds the version's top-level and helmrelease/ kustomizations offline (with the same
+// releaseName/releaseNamespace substitutions the tests use) and verifies the chart reference points at version.
+func RenderCheck(appName, versionPath, version string) error {
+	subs := map[string]string{
+		"releaseNamespace": catalogapptests.DefaultNamespace,
+		"releaseName":      appName,
+	}
+	if _, err := framework.BuildKustomization(versionPath, subs); err != nil {
+		return err
+	}
+	objs, err := framework.BuildKustomization(filepath.Join(versionPath, "helmrelease"), subs)
+	if err != nil {
+		return err
+	}
+	for _, o := range objs {
+		switch o.GetKind() {
+		case "OCIRepository":
+			if tag, ok, _ := unstructured.NestedFieldNoCopy(o.Object, "spec", "ref", "tag"); ok && fmt.Sprint(tag) != version {
+				return fmt.Errorf("OCIRepository %s ref.tag is %q, want %q", o.GetName(), fmt.Sprint(tag), version)
+			}
+		case "HelmRelease":
+			if v, ok, _ := unstructured.NestedFieldNoCopy(o.Object, "spec", "chart", "spec", "version"); ok && fmt.Sprint(v) != version {
+				return fmt.Errorf("HelmRelease %s chart version is %q, want %q", o.GetName(), fmt.Sprint(v), version)
+			}
+		}
+	}
+	return nil
+}
+
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			// Golden files belong to the version they were rendered from.
+			if d.Name() == catalogapptests.SnapshotsDir {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(target, 0o755)
+		}
+		// So is the image inventory; regenerate it for the new chart version.
+		if rel == catalogapptests.ImagesFile {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, b, info.Mode().Perm())
+	})
+}
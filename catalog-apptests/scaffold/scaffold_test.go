@@ -0,0 +1,169 @@
+package scaffold
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/chart"
+
+	catalogapptests "github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests"
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/chartcache"
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/internal/testutil"
+)
+
+func TestScaffold(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Scaffold Suite")
+}
+
+const (
+	topKustomization = "apiVersion: kustomize.config.k8s.io/v1beta1\nkind: Kustomization\nresources:\n- helmrelease.yaml\n"
+	topHelmRelease   = `apiVersion: kustomize.toolkit.fluxcd.io/v1
+kind: Kustomization
+metadata:
+  name: ${releaseName}-helmrelease
+  namespace: ${releaseNamespace}
+spec:
+  interval: 6h0m0s
+  path: ./helmrelease
+  prune: true
+  sourceRef:
+    kind: OCIRepository
+    name: ${releaseName}-source
+`
+	hrKustomization = "apiVersion: kustomize.config.k8s.io/v1beta1\nkind: Kustomization\nresources:\n- cm.yaml\n- helmrelease.yaml\n"
+	cm              = `apiVersion: v1
+data:
+  values.yaml: |
+    image:
+      tag: 1.10.01
+kind: ConfigMap
+metadata:
+  name: ${releaseName}-config-defaults
+  namespace: ${releaseNamespace}
+`
+	helmRelease = `apiVersion: source.toolkit.fluxcd.io/v1
+kind: OCIRepository
+metadata:
+  name: ${releaseName}-chart
+  namespace: ${releaseNamespace}
+spec:
+  interval: 6h0m0s
+  ref:
+    tag: "0.0.0-master-abc123"
+  url: oci://ghcr.io/example/charts/demo
+---
+apiVersion: helm.toolkit.fluxcd.io/v2
+kind: HelmRelease
+metadata:
+  name: demo
+  namespace: ${releaseNamespace}
+spec:
+  chartRef:
+    kind: OCIRepository
+    name: ${releaseName}-chart
+  valuesFrom:
+    - kind: ConfigMap
+      name: ${releaseName}-config-defaults
+`
+	metadata = `schema: catalog.nkp.nutanix.com/v1/application-metadata
+displayName: Demo
+description: |
+  Demo v1.10.0 (not 11.10.0).
+`
+)
+
+var _ = Describe("NewVersion", func() {
+	var appsDir string
+
+	BeforeEach(func() {
+		appsDir = GinkgoT().TempDir()
+		v := filepath.Join(appsDir, "demo", "1.10.0")
+		testutil.WriteFile(filepath.Join(v, "kustomization.yaml"), topKustomization)
+		testutil.WriteFile(filepath.Join(v, "helmrelease.yaml"), topHelmRelease)
+		testutil.WriteFile(filepath.Join(v, "metadata.yaml"), metadata)
+		testutil.WriteFile(filepath.Join(v, "helmrelease", "kustomization.yaml"), hrKustomization)
+		testutil.WriteFile(filepath.Join(v, "helmrelease", "cm.yaml"), cm)
+		testutil.WriteFile(filepath.Join(v, "helmrelease", "helmrelease.yaml"), helmRelease)
+		// 1.9.0 sorts after 1.10.0 lexically; the semver-latest version must be copied.
+		testutil.WriteFile(filepath.Join(appsDir, "demo", "1.9.0", "metadata.yaml"), metadata)
+	})
+
+	It("copies the latest version and rewrites the chart tag and version strings", func() {
+		cat, err := catalogapptests.NewCatalogAt(appsDir)
+		Expect(err).ToNot(HaveOccurred())
+
+		r, err := NewScaffolder(cat).NewVersion(context.Background(), "demo", "1.11.0")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(r.FromVersion).To(Equal("1.10.0"))
+		Expect(r.Rewritten).To(Equal([]string{"helmrelease/helmrelease.yaml", "metadata.yaml"}))
+
+		hr, err := os.ReadFile(filepath.Join(r.Path, "helmrelease", "helmrelease.yaml"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(hr)).To(ContainSubstring(`tag: "1.11.0"`))
+		md, err := os.ReadFile(filepath.Join(r.Path, "metadata.yaml"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(md)).To(ContainSubstring("Demo v1.11.0 (not 11.10.0)."))
+		values, err := os.ReadFile(filepath.Join(r.Path, "helmrelease", "cm.yaml"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(values)).To(Equal(cm))
+	})
+
+	It("refuses to overwrite an existing version", func() {
+		cat, err := catalogapptests.NewCatalogAt(appsDir)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = NewScaffolder(cat, WithFromVersion("1.10.0")).NewVersion(context.Background(), "demo", "1.9.0")
+		Expect(err).To(MatchError(ContainSubstring("already exists")))
+	})
+})
+
+var _ = Describe("NewVersion with a chart cache", func() {
+	var appsDir, cacheDir string
+
+	// cacheChart caches registry.example.com/charts/cached:<version> with the given template.
+	cacheChart := func(version, template string) {
+		testutil.CacheChart(cacheDir, &chart.Chart{
+			Metadata:  &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "cached", Version: version},
+			Templates: []*chart.File{{Name: "templates/cm.yaml", Data: []byte(template)}},
+		})
+	}
+	newVersion := func(version string) (*Result, error) {
+		cat, err := catalogapptests.NewCatalogAt(appsDir)
+		Expect(err).ToNot(HaveOccurred())
+		cache, err := chartcache.NewCache(cacheDir)
+		Expect(err).ToNot(HaveOccurred())
+		return NewScaffolder(cat, WithChartCache(cache)).NewVersion(context.Background(), "cached", version)
+	}
+
+	BeforeEach(func() {
+		appsDir = GinkgoT().TempDir()
+		cacheDir = GinkgoT().TempDir()
+		v := testutil.WriteApp(appsDir, "cached", "1.0.0", testutil.HelmRelease("cached", "cached", "1.0.0", ""), "")
+		testutil.WriteFile(filepath.Join(v, "kustomization.yaml"), topKustomization)
+		testutil.WriteFile(filepath.Join(v, "helmrelease.yaml"), topHelmRelease)
+		testutil.WriteFile(filepath.Join(v, "metadata.yaml"), metadata)
+	})
+
+	It("renders the new chart version with Helm when it is cached", func() {
+		cacheChart("1.1.0", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Release.Name }}\n")
+		r, err := newVersion("1.1.0")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(r.Rendered).To(BeTrue())
+	})
+
+	It("reports Helm render failures", func() {
+		cacheChart("1.1.0", "{{ fail \"broken chart\" }}\n")
+		_, err := newVersion("1.1.0")
+		Expect(err).To(MatchError(ContainSubstring("broken chart")))
+	})
+
+	It("skips the Helm render when the chart is not cached", func() {
+		r, err := newVersion("1.1.0")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(r.Rendered).To(BeFalse())
+	})
+})
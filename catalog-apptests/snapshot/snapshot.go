@@ -0,0 +1,211 @@
+// Package snapshot keeps golden files of each app version's rendered HelmReleases
+// (applications/<app>/<version>/__snapshots__/<helmrelease>.yaml) and reports when the offline render
+// no longer matches them, so chart and values changes are reviewed intentionally.
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+
+	catalogapptests "github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests"
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/render"
+)
+
+// Dir is the golden file directory inside applications/<app>/<version>/.
+const Dir = catalogapptests.SnapshotsDir
+
+// UpdateEnv regenerates golden files instead of comparing when set to "1" or "true".
+const UpdateEnv = "UPDATE_SNAPSHOTS"
+
+// ErrNoSnapshot is returned by Check when the app version has no golden file directory (Dir) yet.
+var ErrNoSnapshot = errors.New("no golden files")
+
+// Mismatch is a golden file that differs from the current render (or is missing or stale).
+type Mismatch struct {
+	App         string
+	Version     string
+	HelmRelease string
+	Path        string
+	// Diff is a unified diff from the golden file to the current render.
+	Diff string
+}
+
+func (m Mismatch) String() string {
+	return fmt.Sprintf("%s/%s: HelmRelease %s does not match %s (set %s=1 to update)\n%s",
+		m.App, m.Version, m.HelmRelease, m.Path, UpdateEnv, m.Diff)
+}
+
+// Snapshotter compares and updates rendered-manifest golden files.
+type Snapshotter interface {
+	// Check renders applications/<app>/<version> (empty version = latest) and compares it with the golden files;
+	// it returns ErrNoSnapshot when the version has no golden file directory.
+	Check(ctx context.Context, appName, version string) ([]Mismatch, error)
+	// Update rewrites the golden files of applications/<app>/<version> and returns the files written.
+	Update(ctx context.Context, appName, version string) ([]string, error)
+}
+
+// Ensure snapshotter implements Snapshotter at compile time.
+var _ Snapshotter = (*snapshotter)(nil)
+
+type snapshotter struct {
+	catalog  catalogapptests.Catalog
+	renderer render.Renderer
+}
+
+// NewSnapshotter returns a Snapshotter rendering app versions with renderer.
+func NewSnapshotter(cat catalogapptests.Catalog, renderer render.Renderer) Snapshotter {
+	return &snapshotter{catalog: cat, renderer: renderer}
+}
+
+// UpdateRequested reports whether UpdateEnv asks for golden files to be regenerated.
+func UpdateRequested() bool {
+	v := strings.ToLower(os.Getenv(UpdateEnv))
+	return v == "1" || v == "true"
+}
+
+func (s *snapshotter) Check(ctx context.Context, appName, version string) ([]Mismatch, error) {
+	versionPath, err := s.catalog.PathToApp(appName, version)
+	if err != nil {
+		return nil, err
+	}
+	if st, err := os.Stat(filepath.Join(versionPath, Dir)); err != nil || !st.IsDir() {
+		return nil, fmt.Errorf("%s/%s: %w in %s", appName, filepath.Base(versionPath), ErrNoSnapshot, Dir)
+	}
+	dir, res, want, err := s.render(ctx, appName, versionPath)
+	if err != nil {
+		return nil, err
+	}
+	var out []Mismatch
+	for _, name := range sortedKeys(want) {
+		path := filepath.Join(dir, name+".yaml")
+		got, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if bytes.Equal(got, want[name]) {
+			continue
+		}
+		out = append(out, Mismatch{
+			App: appName, Version: res.Version, HelmRelease: name, Path: path,
+			Diff: unifiedDiff(path, string(got), string(want[name])),
+		})
+	}
+	stale, err := staleFiles(dir, want)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range stale {
+		got, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, Mismatch{
+			App: appName, Version: res.Version, HelmRelease: strings.TrimSuffix(filepath.Base(path), ".yaml"), Path: path,
+			Diff: unifiedDiff(path, string(got), ""),
+		})
+	}
+	return out, nil
+}
+
+func (s *snapshotter) Update(ctx context.Context, appName, version string) ([]string, error) {
+	versionPath, err := s.catalog.PathToApp(appName, version)
+	if err != nil {
+		return nil, err
+	}
+	dir, _, want, err := s.render(ctx, appName, versionPath)
+	if err != nil {
+		return nil, err
+	}
+	stale, err := staleFiles(dir, want)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range stale {
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	if len(want) == 0 {
+		return nil, nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	var written []string
+	for _, name := range sortedKeys(want) {
+		path := filepath.Join(dir, name+".yaml")
+		if err := os.WriteFile(path, want[name], 0o644); err != nil {
+			return nil, err
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// render renders the app version at versionPath and returns the golden directory, the render result and the
+// expected file content per HelmRelease.
+func (s *snapshotter) render(ctx context.Context, appName, versionPath string) (string, *render.Result, map[string][]byte, error) {
+	res, err := s.renderer.Render(ctx, appName, filepath.Base(versionPath))
+	if err != nil {
+		return "", nil, nil, err
+	}
+	want := make(map[string][]byte, len(res.Releases))
+	for _, rel := range res.Releases {
+		var buf bytes.Buffer
+		if err := render.WriteYAML(&buf, rel.Objects); err != nil {
+			return "", nil, nil, err
+		}
+		want[rel.HelmRelease] = buf.Bytes()
+	}
+	return filepath.Join(versionPath, Dir), res, want, nil
+}
+
+// staleFiles returns golden files in dir that no HelmRelease renders anymore.
+func staleFiles(dir string, want map[string][]byte) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || filepath.Ext(name) != ".yaml" {
+			continue
+		}
+		if _, ok := want[strings.TrimSuffix(name, ".yaml")]; !ok {
+			out = append(out, filepath.Join(dir, name))
+		}
+	}
+	return out, nil
+}
+
+func sortedKeys(m map[string][]byte) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func unifiedDiff(path, golden, current string) string {
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(golden),
+		B:        difflib.SplitLines(current),
+		FromFile: path,
+		ToFile:   "rendered",
+		Context:  3,
+	})
+	return diff
+}
@@ -0,0 +1,162 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+
+	catalogapptests "github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests"
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/chartcache"
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/internal/testutil"
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/render"
+)
+
+var update = flag.Bool("update", false, "regenerate golden files under applications/<app>/<version>/"+Dir)
+
+func TestSnapshot(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Snapshot Suite")
+}
+
+// writeApp writes applications/demo/1.0.0 installing registry.example.com/charts/demo with the given values.
+func writeApp(appsDir, values string) {
+	testutil.WriteApp(appsDir, "demo", "1.0.0", testutil.HelmRelease("demo", "demo", "1.0.0", "values:\n"+values), "")
+}
+
+func cacheChart(cacheDir string) {
+	testutil.CacheChart(cacheDir, &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "demo", Version: "1.0.0"},
+		Raw:      []*chart.File{{Name: chartutil.ValuesfileName, Data: []byte("replicaCount: 1\n")}},
+		Templates: []*chart.File{{Name: "templates/cm.yaml", Data: []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .Release.Name }}
+data:
+  replicas: {{ .Values.replicaCount | quote }}
+`)}},
+	})
+}
+
+var _ = Describe("Snapshotter", func() {
+	var (
+		appsDir, golden string
+		snapshots       Snapshotter
+		ctx             = context.Background()
+	)
+
+	BeforeEach(func() {
+		appsDir = GinkgoT().TempDir()
+		cacheDir := GinkgoT().TempDir()
+		writeApp(appsDir, "  replicaCount: 2\n")
+		cacheChart(cacheDir)
+		cat, err := catalogapptests.NewCatalogAt(appsDir)
+		Expect(err).ToNot(HaveOccurred())
+		cache, err := chartcache.NewCache(cacheDir)
+		Expect(err).ToNot(HaveOccurred())
+		snapshots = NewSnapshotter(cat, render.NewRenderer(cat, cache))
+		golden = filepath.Join(appsDir, "demo", "1.0.0", Dir, "demo.yaml")
+	})
+
+	It("returns ErrNoSnapshot without golden files and writes them on update", func() {
+		_, err := snapshots.Check(ctx, "demo", "")
+		Expect(err).To(MatchError(ErrNoSnapshot))
+
+		written, err := snapshots.Update(ctx, "demo", "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(written).To(Equal([]string{golden}))
+		Expect(os.ReadFile(golden)).To(ContainSubstring(`replicas: "2"`))
+
+		Expect(snapshots.Check(ctx, "demo", "1.0.0")).To(BeEmpty())
+	})
+
+	It("reports golden files missing from the golden directory", func() {
+		Expect(os.MkdirAll(filepath.Dir(golden), 0o755)).To(Succeed())
+		mismatches, err := snapshots.Check(ctx, "demo", "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(mismatches).To(HaveLen(1))
+		Expect(mismatches[0].Path).To(Equal(golden))
+	})
+
+	It("reports a diff when the rendering changes", func() {
+		_, err := snapshots.Update(ctx, "demo", "")
+		Expect(err).ToNot(HaveOccurred())
+		writeApp(appsDir, "  replicaCount: 3\n")
+
+		mismatches, err := snapshots.Check(ctx, "demo", "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(mismatches).To(HaveLen(1))
+		Expect(mismatches[0].Diff).To(ContainSubstring(`-  replicas: "2"`))
+		Expect(mismatches[0].Diff).To(ContainSubstring(`+  replicas: "3"`))
+	})
+
+	It("reports and removes golden files of HelmReleases that no longer exist", func() {
+		stale := filepath.Join(appsDir, "demo", "1.0.0", Dir, "old.yaml")
+		testutil.WriteFile(stale, "---\n")
+		mismatches, err := snapshots.Check(ctx, "demo", "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(mismatches).To(HaveLen(2))
+		Expect(mismatches[1].HelmRelease).To(Equal("old"))
+
+		_, err = snapshots.Update(ctx, "demo", "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stale).ToNot(BeAnExistingFile())
+	})
+})
+
+// Golden files of the real catalog: go test ./snapshot/ -ginkgo.label-filter=catalog [-update].
+// Versions without golden files, or whose chart is not in the chart cache, are skipped (generate them with
+// just snapshots-update <app>; fill the cache with go run ./cmd/render --pull).
+var _ = Describe("Catalog snapshots", Label("catalog"), func() {
+	catalog, err := catalogapptests.DefaultCatalog()
+	if err != nil {
+		Fail("discovery failed: " + err.Error())
+	}
+	apps, err := catalog.Apps()
+	if err != nil {
+		Fail("discovery failed: " + err.Error())
+	}
+	updating := *update || UpdateRequested()
+
+	var snapshots Snapshotter
+	BeforeEach(func() {
+		var opts []chartcache.Option
+		if updating {
+			opts = append(opts, chartcache.WithPull())
+		}
+		cache, err := chartcache.NewCache("", opts...)
+		Expect(err).ToNot(HaveOccurred())
+		snapshots = NewSnapshotter(catalog, render.NewRenderer(catalog, cache))
+	})
+
+	for _, app := range apps {
+		for _, version := range app.Versions {
+			It(app.Name+"/"+version+" should match its rendered snapshot", Label("appname", app.Name), func(ctx SpecContext) {
+				if updating {
+					written, err := snapshots.Update(ctx, app.Name, version)
+					Expect(err).ToNot(HaveOccurred())
+					for _, p := range written {
+						GinkgoWriter.Println("updated", p)
+					}
+					return
+				}
+				mismatches, err := snapshots.Check(ctx, app.Name, version)
+				if errors.Is(err, ErrNoSnapshot) || errors.Is(err, chartcache.ErrNotCached) {
+					Skip(err.Error())
+				}
+				Expect(err).ToNot(HaveOccurred())
+				for _, m := range mismatches {
+					AddReportEntry(m.HelmRelease, m.String())
+				}
+				Expect(mismatches).To(BeEmpty(), "rendered manifests changed; run with -update (or %s=1) to accept", UpdateEnv)
+			})
+		}
+	}
+})
@@ -3,16 +3,25 @@ package catalogapptests
 import (
 	"context"
 	"fmt"
+	"net"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/framework"
 	fluxhelmv2 "github.com/fluxcd/helm-controller/api/v2"
 	apimeta "github.com/fluxcd/pkg/apis/meta"
 	. "github.com/onsi/ginkgo/v2"
+	"github.com/onsi/ginkgo/v2/types"
 	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	ctrlClient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -21,14 +30,39 @@ import (
 var (
 	suiteCtx     context.Context
 	suiteNetwork *framework.Network
+	suiteMirrors *framework.RegistryMirrors // nil unless CATALOG_REGISTRY_MIRRORS=true
 )
 
 var _ = BeforeSuite(func() {
 	log.SetLogger(zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true)))
 	suiteCtx = context.Background()
+})
+
+// setupSuiteNetwork creates the suite network (and registry mirrors) before the first cluster, so specs that
+// create none (Label "unit") run without Docker.
+func setupSuiteNetwork() {
+	if suiteNetwork != nil {
+		return
+	}
 	var err error
-	suiteNetwork, err = framework.EnsureDockerNetworkExist(suiteCtx, "", false)
+	suiteNetwork, err = framework.EnsureNetwork(suiteCtx, SuiteNetworkConfig())
 	Expect(err).ShouldNot(HaveOccurred())
+	if RegistryMirrorsEnabled() {
+		suiteMirrors, err = framework.NewRegistryMirrors(suiteCtx, suiteNetwork.Name, RegistryMirrorsPrefix, framework.DefaultMirroredRegistries)
+		Expect(err).ShouldNot(HaveOccurred())
+	}
+}
+
+var _ = AfterSuite(func() {
+	if os.Getenv("SKIP_CLUSTER_TEARDOWN") != "" {
+		return
+	}
+	if suiteMirrors != nil {
+		Expect(suiteMirrors.Delete(suiteCtx)).To(Succeed())
+	}
+	if suiteNetwork != nil && suiteNetwork.Created && NetworkCleanupEnabled() {
+		Expect(suiteNetwork.Delete(suiteCtx)).To(Succeed())
+	}
 })
 
 func TestCatalogApplications(t *testing.T) {
@@ -58,128 +92,669 @@ func assertHelmReleaseReady(cluster Cluster, name, namespace string, expectUpgra
 	}).WithPolling(PollInterval).WithTimeout(5 * time.Minute).Should(Succeed())
 }
 
+// assertCatalogAppDelivered waits until CatalogAppStatus reports no problems for the app version at appPath
+// (chart sources Ready; in GitOps mode also the Flux Kustomizations and their inventory).
+func assertCatalogAppDelivered(cluster Cluster, app *CatalogApp, appPath string) {
+	Eventually(func() error {
+		return CatalogAppStatus(cluster, app, appPath)
+	}).WithPolling(PollInterval).WithTimeout(5 * time.Minute).Should(Succeed())
+}
+
+// assertLoadBalancerServicesReachable waits until every LoadBalancer Service on the cluster has an address
+// that accepts TCP connections on each of its ports from the test process (LoadBalancerEnv only).
+func assertLoadBalancerServicesReachable(cluster Cluster) {
+	if !LoadBalancerEnabled() {
+		return
+	}
+	var services corev1.ServiceList
+	Expect(cluster.Client().List(cluster.Ctx(), &services)).To(Succeed())
+	for _, svc := range services.Items {
+		if svc.Spec.Type != corev1.ServiceTypeLoadBalancer {
+			continue
+		}
+		Eventually(func() error {
+			addr, err := LoadBalancerAddress(cluster.Ctx(), cluster.Client(), svc.Namespace, svc.Name)
+			if err != nil {
+				return err
+			}
+			for _, port := range svc.Spec.Ports {
+				if port.Protocol != "" && port.Protocol != corev1.ProtocolTCP {
+					continue
+				}
+				conn, err := net.DialTimeout("tcp", net.JoinHostPort(addr, fmt.Sprint(port.Port)), 5*time.Second)
+				if err != nil {
+					return err
+				}
+				_ = conn.Close()
+			}
+			return nil
+		}).WithPolling(PollInterval).WithTimeout(3 * time.Minute).Should(Succeed())
+	}
+}
+
+// assertIngressesServe waits until every Ingress / HTTPRoute host on the cluster is served through the
+// ingress controller over HTTP (IngressEnv only).
+func assertIngressesServe(cluster Cluster) {
+	if !IngressEnabled() {
+		return
+	}
+	client, err := cluster.IngressClient(cluster.Ctx())
+	Expect(err).ToNot(HaveOccurred())
+	hosts, err := IngressHosts(cluster.Ctx(), cluster.Client())
+	Expect(err).ToNot(HaveOccurred())
+	for _, host := range hosts {
+		Eventually(func() error {
+			return IngressServes(client, "http://"+host+"/")
+		}).WithPolling(PollInterval).WithTimeout(3 * time.Minute).Should(Succeed())
+	}
+}
+
+// smokeProbe is an HTTP endpoint of an app requested through a Service port-forward.
+type smokeProbe struct {
+	port         int
+	path         string
+	bodyContains string
+}
+
+// smokeProbes are the endpoints assertSmokeProbe requests per app, on the Service labelled
+// app.kubernetes.io/name=<app>.
+var smokeProbes = map[string]smokeProbe{
+	"podinfo":  {port: 9898, path: "/healthz", bodyContains: "OK"},
+	"opencost": {port: OpenCostAPIPort, path: "/healthz"},
+}
+
+// assertSmokeProbe requests the app's smokeProbes endpoint through a port-forward until it answers 2xx
+// (apps without a probe are skipped).
+func assertSmokeProbe(cluster Cluster, appName string) {
+	probe, ok := smokeProbes[appName]
+	if !ok {
+		return
+	}
+	Eventually(func() error {
+		svc, err := AppService(cluster.Ctx(), cluster.Client(), appName, probe.port)
+		if err != nil {
+			return err
+		}
+		fwd, err := cluster.PortForwardService(cluster.Ctx(), svc.Namespace, svc.Name, probe.port)
+		if err != nil {
+			return err
+		}
+		defer fwd.Close()
+		_, err = framework.HTTPProbe{URL: fwd.URL(probe.path), BodyContains: probe.bodyContains, Attempts: 5}.Run(cluster.Ctx())
+		return err
+	}).WithPolling(PollInterval).WithTimeout(3 * time.Minute).Should(Succeed())
+}
+
+// templatedApps returns the apps the templated suite generates specs for: all catalog apps,
+// or only the changed ones (and their dependents) when BaseRefEnv is set.
+func templatedApps(catalog Catalog) ([]AppVersions, error) {
+	if baseRef := os.Getenv(BaseRefEnv); baseRef != "" {
+		return catalog.ChangedApps(baseRef)
+	}
+	return catalog.Apps()
+}
+
+// appChanged reports whether the templated suite should cover name: always, or only when
+// it is among the changed apps (and their dependents) when BaseRefEnv is set.
+func appChanged(catalog Catalog, name string) bool {
+	baseRef := os.Getenv(BaseRefEnv)
+	if baseRef == "" {
+		return true
+	}
+	changed, err := catalog.ChangedApps(baseRef)
+	if err != nil {
+		Fail("change detection failed: " + err.Error())
+	}
+	for _, av := range changed {
+		if av.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+var _ = Describe("Catalog applications (chart source)", Label("templated", "source"), func() {
+	catalog, err := DefaultCatalog()
+	if err != nil {
+		Fail("discovery failed: " + err.Error())
+	}
+	apps, err := templatedApps(catalog)
+	if err != nil {
+		Fail("discovery failed: " + err.Error())
+	}
+
+	for i := range apps {
+		app := apps[i]
+		It(app.Name+" should reference the source declared in "+CatalogSourceFile, Label("appname", app.Name), func() {
+			Expect(catalog.ValidateCatalogSource(app.Name)).To(Succeed())
+		})
+	}
+})
+
 var _ = Describe("Catalog applications (install/upgrade)", Ordered, Label("templated"), func() {
 	catalog, err := DefaultCatalog()
 	if err != nil {
 		Fail("discovery failed: " + err.Error())
 	}
-	apps, err := catalog.Apps()
+	apps, err := templatedApps(catalog)
 	if err != nil {
 		Fail("discovery failed: " + err.Error())
 	}
 
 	for i := range apps {
 		app := apps[i]
-		Describe(app.Name+" install/upgrade", Ordered, Label("appname", app.Name), func() {
-			var cluster Cluster
+		for _, k8sVersion := range KubernetesVersions() {
+			title, labels := app.Name+" install/upgrade", Label("appname", app.Name)
+			if k8sVersion != "" {
+				title, labels = title+" on "+k8sVersion, Label("appname", app.Name, KubernetesVersionLabel(k8sVersion))
+			}
+			Describe(title, Ordered, labels, func() {
+				var cluster Cluster
+
+				BeforeEach(OncePerOrdered, func() {
+					var err error
+					setupSuiteNetwork()
+					cluster, err = KindCluster.Create(suiteCtx, ClusterConfig{
+						Network:           suiteNetwork,
+						Catalog:           catalog,
+						Name:              "default",
+						KubernetesVersion: k8sVersion,
+						RegistryMirrors:   suiteMirrors,
+						IPFamily:          SuiteNetworkConfig().IPFamily,
+						Ingress:           IngressEnabled(),
+					})
+					Expect(err).ToNot(HaveOccurred())
+					Expect(cluster.Install(FluxApp)).ToNot(HaveOccurred())
+					if LoadBalancerEnabled() {
+						Expect(cluster.Install(LoadBalancerApp)).ToNot(HaveOccurred())
+					}
+					if IngressEnabled() {
+						Expect(cluster.InstallIngress()).ToNot(HaveOccurred())
+					}
+				})
+				AfterEach(OncePerOrdered, func() {
+					if os.Getenv("SKIP_CLUSTER_TEARDOWN") != "" {
+						return
+					}
+					cluster.Destroy()
+				})
+
+				Describe("Installing "+app.Name, Ordered, Label("install"), func() {
+					It("should install successfully with default config", func() {
+						catalogApp := NewCatalogApp(app.Name, "")
+						Expect(cluster.Install(catalogApp)).ToNot(HaveOccurred())
+						assertHelmReleaseReady(cluster, catalogApp.Name(), DefaultNamespace, false)
+						appPath, err := catalog.PathToApp(app.Name, "")
+						Expect(err).ToNot(HaveOccurred())
+						assertCatalogAppDelivered(cluster, catalogApp, appPath)
+						assertSmokeProbe(cluster, app.Name)
+						assertLoadBalancerServicesReachable(cluster)
+						assertIngressesServe(cluster)
+					})
+				})
+
+				if len(app.Versions) >= 2 {
+					Describe("Upgrading "+app.Name, Ordered, Label("upgrade"), func() {
+						var cat *CatalogApp
+						It("should install the previous version successfully", func() {
+							cat = NewCatalogApp(app.Name, "")
+							Expect(cat.InstallPreviousVersion(cluster)).ToNot(HaveOccurred())
+							assertHelmReleaseReady(cluster, cat.Name(), DefaultNamespace, false)
+							appPath, err := catalog.PrevVersionPath(app.Name)
+							Expect(err).ToNot(HaveOccurred())
+							assertCatalogAppDelivered(cluster, cat, appPath)
+							assertSmokeProbe(cluster, app.Name)
+						})
+						It("should upgrade successfully", func() {
+							if cat == nil {
+								cat = NewCatalogApp(app.Name, "")
+							}
+							Expect(cat.Upgrade(cluster)).ToNot(HaveOccurred())
+							assertHelmReleaseReady(cluster, cat.Name(), DefaultNamespace, true)
+							appPath, err := catalog.PathToApp(app.Name, "")
+							Expect(err).ToNot(HaveOccurred())
+							assertCatalogAppDelivered(cluster, cat, appPath)
+							assertSmokeProbe(cluster, app.Name)
+							assertLoadBalancerServicesReachable(cluster)
+							assertIngressesServe(cluster)
+						})
+					})
+				}
+			})
+		}
+	}
+})
+
+var _ = Describe("Ingress (podinfo served through traefik)", Ordered, Label("ingress", "appname", "podinfo"), func() {
+	var cluster Cluster
+
+	BeforeAll(func() {
+		if !IngressEnabled() {
+			Skip("set " + IngressEnv + "=true to run ingress specs")
+		}
+		catalog, err := DefaultCatalog()
+		Expect(err).ToNot(HaveOccurred())
+		setupSuiteNetwork()
+		cluster, err = KindCluster.Create(suiteCtx, ClusterConfig{
+			Network:         suiteNetwork,
+			Catalog:         catalog,
+			Name:            "ingress",
+			RegistryMirrors: suiteMirrors,
+			IPFamily:        SuiteNetworkConfig().IPFamily,
+			Ingress:         true,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cluster.Install(FluxApp)).ToNot(HaveOccurred())
+		Expect(cluster.InstallIngress()).ToNot(HaveOccurred())
+	})
+	AfterAll(func() {
+		if cluster == nil || os.Getenv("SKIP_CLUSTER_TEARDOWN") != "" {
+			return
+		}
+		cluster.Destroy()
+	})
+
+	It("should serve podinfo by hostname", func() {
+		podinfo := NewCatalogApp("podinfo", "")
+		Expect(cluster.Install(podinfo)).ToNot(HaveOccurred())
+		assertHelmReleaseReady(cluster, podinfo.Name(), DefaultNamespace, false)
+
+		var services corev1.ServiceList
+		Eventually(func() error {
+			if err := cluster.Client().List(cluster.Ctx(), &services, ctrlClient.InNamespace(DefaultNamespace),
+				ctrlClient.MatchingLabels{"app.kubernetes.io/name": "podinfo"}); err != nil {
+				return err
+			}
+			if len(services.Items) == 0 {
+				return fmt.Errorf("no podinfo Service yet")
+			}
+			return nil
+		}).WithPolling(PollInterval).WithTimeout(time.Minute).Should(Succeed())
+		Expect(ExposeService(cluster.Ctx(), cluster.Client(), &services.Items[0], "podinfo.catalog.test")).To(Succeed())
+
+		client, err := cluster.IngressClient(cluster.Ctx())
+		Expect(err).ToNot(HaveOccurred())
+		Eventually(func() error {
+			return IngressServes(client, "http://podinfo.catalog.test/")
+		}).WithPolling(PollInterval).WithTimeout(3 * time.Minute).Should(Succeed())
+	})
+})
+
+// Kubernetes version matrix: with KIND_K8S_VERSIONS set, print per app the versions all its install/upgrade
+// specs passed on, i.e. the minimum and maximum supported cluster version.
+var _ = ReportAfterSuite("Kubernetes version matrix", func(report Report) {
+	if os.Getenv(KubernetesVersionsEnv) == "" {
+		return
+	}
+	type result struct{ passed, failed map[string]bool }
+	results := map[string]*result{}
+	for _, spec := range report.SpecReports {
+		if spec.LeafNodeType != types.NodeTypeIt || spec.State == types.SpecStateSkipped {
+			continue
+		}
+		app, version := "", ""
+		labels := spec.Labels()
+		for i, l := range labels {
+			if l == "appname" && i+1 < len(labels) {
+				app = labels[i+1]
+			}
+			if strings.HasPrefix(l, "k8s-") {
+				version = strings.TrimPrefix(l, "k8s-")
+			}
+		}
+		if app == "" || version == "" {
+			continue
+		}
+		r := results[app]
+		if r == nil {
+			r = &result{passed: map[string]bool{}, failed: map[string]bool{}}
+			results[app] = r
+		}
+		if spec.State.Is(types.SpecStatePassed) {
+			r.passed[version] = true
+		} else {
+			r.failed[version] = true
+		}
+	}
+	names := make([]string, 0, len(results))
+	for n := range results {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	fmt.Println("\nKubernetes version matrix:")
+	for _, n := range names {
+		r := results[n]
+		// Passing labels that are not semver (e.g. a custom node image tag) cannot bound the range;
+		// they are listed separately.
+		var ok []*semver.Version
+		var unparsed []string
+		for v := range r.passed {
+			if r.failed[v] {
+				continue
+			}
+			sv, err := semver.NewVersion(v)
+			if err != nil {
+				unparsed = append(unparsed, v)
+				continue
+			}
+			ok = append(ok, sv)
+		}
+		var failed []string
+		for v := range r.failed {
+			failed = append(failed, v)
+		}
+		SortVersions(failed)
+		SortVersions(unparsed)
+		if len(ok) == 0 {
+			fmt.Printf("  %s: no passing semver version", n)
+		} else {
+			sort.Sort(semver.Collection(ok))
+			fmt.Printf("  %s: min v%s, max v%s", n, ok[0], ok[len(ok)-1])
+		}
+		if len(unparsed) > 0 {
+			fmt.Printf(" (passed, not semver: %s)", strings.Join(unparsed, ", "))
+		}
+		if len(failed) > 0 {
+			fmt.Printf(" (failed: %s)", strings.Join(failed, ", "))
+		}
+		fmt.Println()
+	}
+})
 
-			BeforeEach(OncePerOrdered, func() {
-				var err error
-				cluster, err = KindCluster.Create(suiteCtx, ClusterConfig{Network: suiteNetwork, Catalog: catalog, Name: "default"})
+var _ = Describe("Catalog applications (multicluster)", Label("templated", "multicluster"), func() {
+	catalog, err := DefaultCatalog()
+	if err != nil {
+		Fail("discovery failed: " + err.Error())
+	}
+	placements, err := SuitePlacements()
+	if err != nil {
+		Fail("placements: " + err.Error())
+	}
+	pairs := placements.Pairs
+	if baseRef := os.Getenv(BaseRefEnv); baseRef != "" {
+		changed, err := catalog.ChangedApps(baseRef)
+		if err != nil {
+			Fail("change detection failed: " + err.Error())
+		}
+		pairs = placements.PairsFor(changed)
+	}
+
+	for i := range pairs {
+		pair := pairs[i]
+		Describe(pair.Name+": "+pair.Central+" on mgmt, "+pair.Client+" on workloads", Ordered, Label("appname", pair.Central, pair.Client), func() {
+			var mgmt NKPManagementCluster
+			var workloads []NKPWorkloadCluster
+
+			BeforeAll(func() {
+				for _, app := range []string{pair.Central, pair.Client} {
+					if _, err := catalog.PathToApp(app, ""); err != nil {
+						Skip(app + " not in catalog — add applications/" + app)
+					}
+				}
+				setupSuiteNetwork()
+				c, err := KindCluster.Create(suiteCtx, ClusterConfig{
+					Network:         suiteNetwork,
+					Catalog:         catalog,
+					Name:            pair.Name + "-mgmt",
+					RegistryMirrors: suiteMirrors,
+					IPFamily:        SuiteNetworkConfig().IPFamily,
+				})
 				Expect(err).ToNot(HaveOccurred())
-				Expect(cluster.Install(FluxApp)).ToNot(HaveOccurred())
+				mgmt = c.(NKPManagementCluster)
+				for _, name := range []string{"workload1", "workload2"} {
+					w, err := KindCluster.CreateFromParent(suiteCtx, mgmt, pair.Name+"-"+name)
+					Expect(err).ToNot(HaveOccurred())
+					workloads = append(workloads, w)
+				}
+				for _, c := range append([]Cluster{mgmt}, asClusters(workloads)...) {
+					Expect(c.Install(FluxApp)).ToNot(HaveOccurred())
+				}
 			})
-			AfterEach(OncePerOrdered, func() {
-				if os.Getenv("SKIP_CLUSTER_TEARDOWN") != "" {
+			AfterAll(func() {
+				if mgmt == nil || os.Getenv("SKIP_CLUSTER_TEARDOWN") != "" {
 					return
 				}
-				cluster.Destroy()
+				mgmt.Destroy()
 			})
 
-			Describe("Installing "+app.Name, Ordered, Label("install"), func() {
-				It("should install successfully with default config", func() {
-					catalogApp := NewCatalogApp(app.Name, "")
-					Expect(cluster.Install(catalogApp)).ToNot(HaveOccurred())
-					assertHelmReleaseReady(cluster, catalogApp.Name(), DefaultNamespace, false)
+			It("should install "+pair.Central+" on mgmt (central)", func() {
+				Expect(mgmt.InstallForRole(pair)).ToNot(HaveOccurred())
+				assertHelmReleaseReady(mgmt, pair.Central, DefaultNamespace, false)
+				assertSmokeProbe(mgmt, pair.Central)
+			})
+			It("should install "+pair.Client+" on every workload (client)", func() {
+				for _, w := range workloads {
+					Expect(w.InstallForRole(pair)).ToNot(HaveOccurred())
+					assertHelmReleaseReady(w, pair.Client, DefaultNamespace, false)
+					assertSmokeProbe(w, pair.Client)
+				}
+			})
+			if e := pair.Endpoint; e != nil {
+				It("should reach "+pair.Central+" from workload pods over the shared network", func() {
+					var addr string
+					Eventually(func() error {
+						svc, err := AppService(mgmt.Ctx(), mgmt.Client(), e.Service, e.Port)
+						if err != nil {
+							return err
+						}
+						addr, err = mgmt.ExposeServiceOnNetwork(mgmt.Ctx(), svc.Namespace, svc.Name, e.Port)
+						return err
+					}).WithPolling(PollInterval).WithTimeout(3 * time.Minute).Should(Succeed())
+					for _, w := range workloads {
+						Eventually(func() error {
+							_, err := w.HTTPGetFromPod(w.Ctx(), "http://"+addr+e.Path)
+							return err
+						}).WithPolling(PollInterval).WithTimeout(5 * time.Minute).Should(Succeed())
+					}
 				})
+			}
+			It("should register the workload clusters on mgmt with peer kubeconfigs", func() {
+				for _, w := range workloads {
+					name, err := mgmt.RegisterWorkloadCluster(mgmt.Ctx(), w)
+					Expect(err).ToNot(HaveOccurred())
+					secret := &corev1.Secret{}
+					Expect(mgmt.Client().Get(mgmt.Ctx(), ctrlClient.ObjectKey{Namespace: DefaultNamespace, Name: name}, secret)).To(Succeed())
+					Expect(string(secret.Data[KubeconfigSecretKey])).To(ContainSubstring("-control-plane:6443"))
+				}
 			})
-
-			if len(app.Versions) >= 2 {
-				Describe("Upgrading "+app.Name, Ordered, Label("upgrade"), func() {
-					var cat *CatalogApp
-					It("should install the previous version successfully", func() {
-						cat = NewCatalogApp(app.Name, "")
-						Expect(cat.InstallPreviousVersion(cluster)).ToNot(HaveOccurred())
-						assertHelmReleaseReady(cluster, cat.Name(), DefaultNamespace, false)
-					})
-					It("should upgrade successfully", func() {
-						if cat == nil {
-							cat = NewCatalogApp(app.Name, "")
+			for _, check := range pair.CentralChecks {
+				It("should serve "+check.Path+" from "+pair.Central+" on mgmt", func() {
+					Eventually(func() error {
+						svc, err := AppService(mgmt.Ctx(), mgmt.Client(), check.Service, check.Port)
+						if err != nil {
+							return err
 						}
-						Expect(cat.Upgrade(cluster)).ToNot(HaveOccurred())
-						assertHelmReleaseReady(cluster, cat.Name(), DefaultNamespace, true)
-					})
+						fwd, err := mgmt.PortForwardService(mgmt.Ctx(), svc.Namespace, svc.Name, check.Port)
+						if err != nil {
+							return err
+						}
+						defer fwd.Close()
+						_, err = framework.HTTPProbe{URL: fwd.URL(check.Path), BodyContains: check.BodyContains, Attempts: 3}.Run(mgmt.Ctx())
+						return err
+					}).WithPolling(10 * time.Second).WithTimeout(10 * time.Minute).Should(Succeed())
 				})
 			}
 		})
 	}
 })
 
-var _ = Describe("Catalog applications (multicluster — OpenCost)", Ordered, Label("templated", "multicluster"), func() {
+// asClusters returns workloads as Clusters.
+func asClusters(workloads []NKPWorkloadCluster) []Cluster {
+	clusters := make([]Cluster, 0, len(workloads))
+	for _, w := range workloads {
+		clusters = append(clusters, w)
+	}
+	return clusters
+}
+
+var _ = Describe("Catalog applications (multicluster — Karmada)", Ordered, Label("templated", "multicluster", "karmada", "appname", KarmadaAppName), func() {
 	catalog, err := DefaultCatalog()
 	if err != nil {
 		Fail("discovery failed: " + err.Error())
 	}
-	apps, err := catalog.Apps()
-	if err != nil {
-		Fail("discovery failed: " + err.Error())
+	if !appChanged(catalog, KarmadaAppName) {
+		return
 	}
 
-	var centralApp, opencostApp *AppVersions
-	for i := range apps {
-		if apps[i].Name == MulticlusterCentralAppName {
-			centralApp = &apps[i]
+	memberNames := []string{"member1", "member2"}
+	var mgmt NKPManagementCluster
+	var members []NKPWorkloadCluster
+	var karmada *Karmada
+
+	BeforeAll(func() {
+		if _, err := catalog.PathToApp(KarmadaAppName, ""); err != nil {
+			Skip(KarmadaAppName + " not in catalog — add applications/" + KarmadaAppName)
 		}
-		if apps[i].Name == MulticlusterTestAppName {
-			opencostApp = &apps[i]
+		setupSuiteNetwork()
+		c, err := KindCluster.Create(suiteCtx, ClusterConfig{
+			Network:         suiteNetwork,
+			Catalog:         catalog,
+			Name:            "karmada",
+			RegistryMirrors: suiteMirrors,
+			IPFamily:        SuiteNetworkConfig().IPFamily,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		mgmt = c.(NKPManagementCluster)
+		for _, name := range memberNames {
+			w, err := KindCluster.CreateFromParent(suiteCtx, mgmt, name)
+			Expect(err).ToNot(HaveOccurred())
+			members = append(members, w)
 		}
+		Expect(mgmt.Install(FluxApp)).ToNot(HaveOccurred())
+	})
+	AfterAll(func() {
+		if karmada != nil {
+			karmada.Close()
+		}
+		if mgmt == nil || os.Getenv("SKIP_CLUSTER_TEARDOWN") != "" {
+			return
+		}
+		mgmt.Destroy()
+	})
+
+	It("should install karmada-operator on mgmt", func() {
+		Expect(mgmt.Install(NewCatalogApp(KarmadaAppName, ""))).ToNot(HaveOccurred())
+		assertHelmReleaseReady(mgmt, KarmadaAppName, DefaultNamespace, false)
+	})
+	It("should create a Karmada instance", func() {
+		var err error
+		karmada, err = CreateKarmada(mgmt.Ctx(), mgmt)
+		Expect(err).ToNot(HaveOccurred())
+	})
+	It("should join the workload clusters with in-network kubeconfigs", func() {
+		for _, w := range members {
+			Expect(karmada.Join(mgmt.Ctx(), w)).To(Succeed())
+		}
+	})
+	It("should propagate podinfo to every member", func() {
+		image := "ghcr.io/stefanprodan/podinfo:latest"
+		if path, err := catalog.PathToApp("podinfo", ""); err == nil {
+			image = "ghcr.io/stefanprodan/podinfo:" + filepath.Base(path)
+		}
+		deployment := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": "podinfo", "namespace": DefaultNamespace},
+			"spec": map[string]interface{}{
+				"replicas": int64(1),
+				"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "podinfo"}},
+				"template": map[string]interface{}{
+					"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "podinfo"}},
+					"spec": map[string]interface{}{"containers": []interface{}{map[string]interface{}{
+						"name":  "podinfo",
+						"image": image,
+						"ports": []interface{}{map[string]interface{}{"containerPort": int64(9898)}},
+					}}},
+				},
+			},
+		}}
+		Expect(karmada.Propagate(mgmt.Ctx(), deployment, memberNames...)).To(Succeed())
+
+		for _, w := range members {
+			Eventually(func() error {
+				var d appsv1.Deployment
+				if err := w.Client().Get(w.Ctx(), ctrlClient.ObjectKey{Namespace: DefaultNamespace, Name: "podinfo"}, &d); err != nil {
+					return err
+				}
+				if d.Status.AvailableReplicas < 1 {
+					return fmt.Errorf("podinfo not available yet")
+				}
+				return nil
+			}).WithPolling(PollInterval).WithTimeout(5 * time.Minute).Should(Succeed())
+		}
+	})
+})
+
+var _ = Describe("Catalog applications (multicluster — Flux remote delivery)", Ordered, Label("templated", "multicluster", "remote", "appname", "podinfo"), func() {
+	catalog, err := DefaultCatalog()
+	if err != nil {
+		Fail("discovery failed: " + err.Error())
+	}
+	if !appChanged(catalog, "podinfo") {
+		return
 	}
 
 	var mgmt NKPManagementCluster
-	var workload1, workload2 NKPWorkloadCluster
+	var workloads []NKPWorkloadCluster
+	namespaces := map[NKPWorkloadCluster]string{}
 
-	BeforeEach(OncePerOrdered, func() {
-		var err error
-		var c Cluster
-		c, err = KindCluster.Create(suiteCtx, ClusterConfig{Network: suiteNetwork, Catalog: catalog, Name: "mgmt"})
+	BeforeAll(func() {
+		if _, err := catalog.PathToApp("podinfo", ""); err != nil {
+			Skip("podinfo not in catalog — add applications/podinfo")
+		}
+		setupSuiteNetwork()
+		c, err := KindCluster.Create(suiteCtx, ClusterConfig{
+			Network:         suiteNetwork,
+			Catalog:         catalog,
+			Name:            "hub",
+			RegistryMirrors: suiteMirrors,
+			IPFamily:        SuiteNetworkConfig().IPFamily,
+		})
 		Expect(err).ToNot(HaveOccurred())
 		mgmt = c.(NKPManagementCluster)
-		workload1, err = KindCluster.CreateFromParent(suiteCtx, mgmt, "workload1")
-		Expect(err).ToNot(HaveOccurred())
-		workload2, err = KindCluster.CreateFromParent(suiteCtx, mgmt, "workload2")
-		Expect(err).ToNot(HaveOccurred())
+		for _, name := range []string{"spoke1", "spoke2"} {
+			w, err := KindCluster.CreateFromParent(suiteCtx, mgmt, name)
+			Expect(err).ToNot(HaveOccurred())
+			workloads = append(workloads, w)
+		}
+		// Only mgmt runs Flux: the workloads receive their releases from its helm-controller.
 		Expect(mgmt.Install(FluxApp)).ToNot(HaveOccurred())
-		Expect(workload1.Install(FluxApp)).ToNot(HaveOccurred())
-		Expect(workload2.Install(FluxApp)).ToNot(HaveOccurred())
 	})
-	AfterEach(OncePerOrdered, func() {
-		if os.Getenv("SKIP_CLUSTER_TEARDOWN") != "" {
+	AfterAll(func() {
+		if mgmt == nil || os.Getenv("SKIP_CLUSTER_TEARDOWN") != "" {
 			return
 		}
 		mgmt.Destroy()
 	})
 
-	Describe("OpenCost central on mgmt, clients on workload", Ordered, Label("appname", MulticlusterTestAppName), func() {
-		It("should install centralized-opencost on mgmt (central)", func() {
-			if centralApp == nil {
-				Skip("centralized-opencost not in catalog — add applications/centralized-opencost")
-			}
-			Expect(mgmt.InstallCentralizedOpencost()).ToNot(HaveOccurred())
-			assertHelmReleaseReady(mgmt, "centralized-opencost", DefaultNamespace, false)
-		})
-		It("should install opencost on workload1 (client)", func() {
-			if opencostApp == nil {
-				Skip("opencost not in catalog — add applications/opencost")
-			}
-			Expect(workload1.InstallOpencost()).ToNot(HaveOccurred())
-			assertHelmReleaseReady(workload1, "opencost", DefaultNamespace, false)
-		})
-		It("should install opencost on workload2 (client)", func() {
-			if opencostApp == nil {
-				Skip("opencost not in catalog — add applications/opencost")
-			}
-			Expect(workload2.InstallOpencost()).ToNot(HaveOccurred())
-			assertHelmReleaseReady(workload2, "opencost", DefaultNamespace, false)
-		})
+	It("should apply podinfo HelmReleases on mgmt that target each workload", func() {
+		for _, w := range workloads {
+			namespace, err := mgmt.InstallOnWorkload(NewCatalogApp("podinfo", ""), w)
+			Expect(err).ToNot(HaveOccurred())
+			namespaces[w] = namespace
+		}
+	})
+	It("should deploy the releases on the workloads", func() {
+		for _, w := range workloads {
+			assertRemoteReleasesDeployed(mgmt, w, namespaces[w])
+		}
+	})
+	It("should serve podinfo on the workloads", func() {
+		for _, w := range workloads {
+			assertSmokeProbe(w, "podinfo")
+		}
 	})
 })
+
+// assertRemoteReleasesDeployed waits until every HelmRelease in namespace on mgmt has landed on workload (see
+// RemoteReleaseDeployed).
+func assertRemoteReleasesDeployed(mgmt NKPManagementCluster, workload NKPWorkloadCluster, namespace string) {
+	var releases fluxhelmv2.HelmReleaseList
+	Expect(mgmt.Client().List(mgmt.Ctx(), &releases, ctrlClient.InNamespace(namespace))).To(Succeed())
+	Expect(releases.Items).ToNot(BeEmpty(), "no HelmReleases in %s on mgmt", namespace)
+	for _, hr := range releases.Items {
+		Eventually(func() error {
+			return RemoteReleaseDeployed(mgmt.Ctx(), mgmt.Client(), workload.Client(), namespace, hr.Name)
+		}).WithPolling(PollInterval).WithTimeout(5 * time.Minute).Should(Succeed())
+	}
+}
@@ -0,0 +1,200 @@
+// Package valuescheck validates each app's defaults ConfigMap (${releaseName}-config-defaults) against the
+// chart it configures, offline: against values.schema.json when the chart has one, otherwise by flagging
+// top-level keys that do not exist in the chart's default values.
+package valuescheck
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	catalogapptests "github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests"
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/chartcache"
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/framework"
+)
+
+// DefaultsConfigMapSuffix is the suffix of the ConfigMap carrying an app's default values.
+const DefaultsConfigMapSuffix = "-config-defaults"
+
+// Finding is one problem in an app version's default values.
+type Finding struct {
+	App         string
+	Version     string
+	HelmRelease string
+	ConfigMap   string
+	// Key is the offending top-level key (unknown-key check); empty for schema errors.
+	Key     string
+	Message string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s/%s: HelmRelease %s ConfigMap %s: %s", f.App, f.Version, f.HelmRelease, f.ConfigMap, f.Message)
+}
+
+// Checker validates default values of catalog app versions.
+type Checker interface {
+	// Check validates applications/<app>/<version> (empty version = latest).
+	Check(ctx context.Context, appName, version string) ([]Finding, error)
+}
+
+// Ensure checker implements Checker at compile time.
+var _ Checker = (*checker)(nil)
+
+type checker struct {
+	catalog catalogapptests.Catalog
+	charts  chartcache.Cache
+}
+
+// NewChecker returns a Checker loading charts from the given cache.
+func NewChecker(cat catalogapptests.Catalog, charts chartcache.Cache) Checker {
+	return &checker{catalog: cat, charts: charts}
+}
+
+func (c *checker) Check(ctx context.Context, appName, version string) ([]Finding, error) {
+	versionPath, err := c.catalog.PathToApp(appName, version)
+	if err != nil {
+		return nil, err
+	}
+	version = filepath.Base(versionPath)
+	objs, err := framework.BuildKustomization(filepath.Join(versionPath, "helmrelease"), map[string]string{
+		"releaseNamespace": catalogapptests.DefaultNamespace,
+		"releaseName":      appName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []Finding
+	for _, hr := range objs {
+		if hr.GetKind() != "HelmRelease" {
+			continue
+		}
+		cms := defaultsConfigMaps(hr, objs)
+		if len(cms) == 0 {
+			continue
+		}
+		ref, err := chartcache.RefFromHelmRelease(hr, objs)
+		if err != nil {
+			return nil, err
+		}
+		chrt, err := c.charts.Get(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		for _, cm := range cms {
+			base := Finding{App: appName, Version: version, HelmRelease: hr.GetName(), ConfigMap: cm.name}
+			values, err := chartutil.ReadValues([]byte(cm.values))
+			if err != nil {
+				f := base
+				f.Message = fmt.Sprintf("%s is not valid YAML: %v", cm.key, err)
+				findings = append(findings, f)
+				continue
+			}
+			for _, msg := range validate(chrt, values) {
+				f := base
+				f.Key, f.Message = msg.key, msg.message
+				findings = append(findings, f)
+			}
+		}
+	}
+	return findings, nil
+}
+
+type configMapValues struct {
+	name   string
+	key    string
+	values string
+}
+
+// defaultsConfigMaps returns the HelmRelease valuesFrom ConfigMaps named *-config-defaults that are part of the
+// kustomization (values at a targetPath are skipped: they are not top-level chart values).
+func defaultsConfigMaps(hr *unstructured.Unstructured, objs []*unstructured.Unstructured) []configMapValues {
+	refs, _, _ := unstructured.NestedSlice(hr.Object, "spec", "valuesFrom")
+	var out []configMapValues
+	for _, r := range refs {
+		m, ok := r.(map[string]interface{})
+		if !ok || m["kind"] != "ConfigMap" || m["targetPath"] != nil {
+			continue
+		}
+		name, _ := m["name"].(string)
+		if !strings.HasSuffix(name, DefaultsConfigMapSuffix) {
+			continue
+		}
+		key, _ := m["valuesKey"].(string)
+		if key == "" {
+			key = chartutil.ValuesfileName
+		}
+		for _, o := range objs {
+			if o.GetKind() != "ConfigMap" || o.GetName() != name {
+				continue
+			}
+			data, _, _ := unstructured.NestedStringMap(o.Object, "data")
+			out = append(out, configMapValues{name: name, key: key, values: data[key]})
+		}
+	}
+	return out
+}
+
+type violation struct {
+	key     string
+	message string
+}
+
+// validate checks values against chrt: with values.schema.json, the values coalesced with the chart defaults
+// are validated the way helm install does; without a schema, top-level keys that are not chart defaults,
+// subchart names/aliases or "global" are reported.
+func validate(chrt *chart.Chart, values map[string]interface{}) []violation {
+	if hasSchema(chrt) {
+		coalesced, err := chartutil.CoalesceValues(chrt, values)
+		if err != nil {
+			return []violation{{message: err.Error()}}
+		}
+		if err := chartutil.ValidateAgainstSchema(chrt, coalesced); err != nil {
+			return []violation{{message: "values do not match values.schema.json: " + strings.TrimSpace(err.Error())}}
+		}
+		return nil
+	}
+	known := map[string]bool{chartutil.GlobalKey: true}
+	for k := range chrt.Values {
+		known[k] = true
+	}
+	if chrt.Metadata != nil {
+		for _, d := range chrt.Metadata.Dependencies {
+			known[d.Name] = true
+			if d.Alias != "" {
+				known[d.Alias] = true
+			}
+		}
+	}
+	var keys []string
+	for k := range values {
+		if !known[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	out := make([]violation, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, violation{key: k, message: fmt.Sprintf("top-level key %q is not in the chart's default values", k)})
+	}
+	return out
+}
+
+// hasSchema reports whether the chart or any subchart ships values.schema.json.
+func hasSchema(chrt *chart.Chart) bool {
+	if len(chrt.Schema) > 0 {
+		return true
+	}
+	for _, d := range chrt.Dependencies() {
+		if hasSchema(d) {
+			return true
+		}
+	}
+	return false
+}
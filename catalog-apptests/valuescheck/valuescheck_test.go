@@ -0,0 +1,91 @@
+package valuescheck
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+
+	catalogapptests "github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests"
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/chartcache"
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/internal/testutil"
+)
+
+func TestValuesCheck(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Values Check Suite")
+}
+
+const schema = `{
+  "$schema": "http://json-schema.org/schema#",
+  "type": "object",
+  "properties": {
+    "replicaCount": {"type": "integer"}
+  }
+}`
+
+// writeApp writes applications/<name>/<version> with an OCIRepository chart and the given defaults values.
+func writeApp(appsDir, name, version, values string) {
+	testutil.WriteApp(appsDir, name, version, testutil.HelmRelease(name, name, version, `valuesFrom:
+  - kind: ConfigMap
+    name: ${releaseName}-config-defaults
+`), testutil.DefaultsConfigMap(values))
+}
+
+// cacheChart saves a chart where the cache expects registry.example.com/charts/<name>:<version>.
+func cacheChart(cacheDir, name, version string, withSchema bool) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: name, Version: version},
+		// Save writes values.yaml from Raw; the loader parses it back into Values.
+		Raw: []*chart.File{{Name: chartutil.ValuesfileName, Data: []byte("replicaCount: 1\nimage:\n  tag: \"\"\n")}},
+	}
+	if withSchema {
+		c.Schema = []byte(schema)
+	}
+	testutil.CacheChart(cacheDir, c)
+}
+
+var _ = Describe("Checker", func() {
+	var (
+		appsDir, cacheDir string
+		checker           Checker
+	)
+
+	BeforeEach(func() {
+		appsDir = GinkgoT().TempDir()
+		cacheDir = GinkgoT().TempDir()
+		writeApp(appsDir, "withschema", "1.0.0", "replicaCount: two\n")
+		writeApp(appsDir, "noschema", "2.0.0", "replicaCount: 2\nimage:\n  tag: v1\nreplicas: 3\n")
+		writeApp(appsDir, "clean", "3.0.0", "")
+		cacheChart(cacheDir, "withschema", "1.0.0", true)
+		cacheChart(cacheDir, "noschema", "2.0.0", false)
+		cat, err := catalogapptests.NewCatalogAt(appsDir)
+		Expect(err).ToNot(HaveOccurred())
+		cache, err := chartcache.NewCache(cacheDir)
+		Expect(err).ToNot(HaveOccurred())
+		checker = NewChecker(cat, cache)
+	})
+
+	It("validates against values.schema.json when present", func() {
+		findings, err := checker.Check(context.Background(), "withschema", "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(findings).To(HaveLen(1))
+		Expect(findings[0].ConfigMap).To(Equal("withschema-config-defaults"))
+		Expect(findings[0].Message).To(ContainSubstring("replicaCount"))
+	})
+
+	It("flags unknown top-level keys without a schema", func() {
+		findings, err := checker.Check(context.Background(), "noschema", "2.0.0")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(findings).To(HaveLen(1))
+		Expect(findings[0].Key).To(Equal("replicas"))
+	})
+
+	It("reports charts missing from the cache", func() {
+		_, err := checker.Check(context.Background(), "clean", "")
+		Expect(err).To(MatchError(chartcache.ErrNotCached))
+	})
+})
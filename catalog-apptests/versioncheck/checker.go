@@ -0,0 +1,185 @@
+// Package versioncheck discovers newer upstream chart versions for catalog apps
+// (Go port of scripts/check-latest-versions.sh).
+package versioncheck
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+
+	catalogapptests "github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests"
+)
+
+// Result is the outcome of checking one app.
+type Result struct {
+	App     string
+	Source  *Source
+	Current string   // latest version directory in the catalog
+	Latest  string   // highest upstream version without a leading "v" (empty if none found)
+	Newer   []string // upstream versions newer than Current, oldest first (no leading "v")
+}
+
+// UpToDate reports whether no newer upstream version exists.
+func (r Result) UpToDate() bool { return len(r.Newer) == 0 }
+
+// Command returns the catalog-workflow.sh add-app command for the latest version.
+func (r Result) Command() string {
+	if r.Source == nil || r.UpToDate() {
+		return ""
+	}
+	if r.Source.HelmRepo != "" {
+		cmd := fmt.Sprintf("./catalog-workflow.sh add-app --appname %s --version %s --helmrepo %s --ocipush %s",
+			r.App, r.Latest, r.Source.HelmRepo, r.Source.OCIPush)
+		if r.Source.URL != "" {
+			cmd += " --helmrepo-url " + r.Source.URL
+		}
+		return cmd
+	}
+	return fmt.Sprintf("./catalog-workflow.sh add-app --appname %s --version %s --ocirepo %s", r.App, r.Latest, r.Source.URL)
+}
+
+// Checker reports newer upstream versions for catalog apps.
+type Checker interface {
+	// Check checks one app by name.
+	Check(ctx context.Context, appName string) (Result, error)
+	// CheckAll checks every app in the catalog; per-app errors are returned alongside results.
+	CheckAll(ctx context.Context) ([]Result, map[string]error, error)
+}
+
+// Ensure checker implements Checker at compile time.
+var _ Checker = (*checker)(nil)
+
+type checker struct {
+	catalog            catalogapptests.Catalog
+	oci                TagLister
+	helm               TagLister
+	includePrereleases bool
+}
+
+// Option configures a Checker.
+type Option func(*checker)
+
+// WithHTTPClient sets the HTTP client used for registries and Helm indexes.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *checker) {
+		c.oci = NewOCIRegistry(client)
+		c.helm = NewHelmIndex(client)
+	}
+}
+
+// WithTagListers overrides the OCI and Helm index listers.
+func WithTagListers(oci, helm TagLister) Option {
+	return func(c *checker) {
+		c.oci = oci
+		c.helm = helm
+	}
+}
+
+// WithPrereleases includes pre-release versions (e.g. 1.2.0-rc.1) in the results.
+func WithPrereleases() Option {
+	return func(c *checker) { c.includePrereleases = true }
+}
+
+// NewChecker returns a Checker over the given catalog.
+func NewChecker(cat catalogapptests.Catalog, opts ...Option) Checker {
+	c := &checker{catalog: cat, oci: NewOCIRegistry(nil), helm: NewHelmIndex(nil)}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+func (c *checker) Check(ctx context.Context, appName string) (Result, error) {
+	apps, err := c.catalog.Apps()
+	if err != nil {
+		return Result{}, err
+	}
+	for _, av := range apps {
+		if av.Name == appName {
+			return c.check(ctx, av)
+		}
+	}
+	return Result{}, fmt.Errorf("application %q not found in catalog", appName)
+}
+
+func (c *checker) CheckAll(ctx context.Context) ([]Result, map[string]error, error) {
+	var results []Result
+	errs := make(map[string]error)
+	err := c.catalog.Each(func(av catalogapptests.AppVersions) error {
+		r, err := c.check(ctx, av)
+		if err != nil {
+			errs[av.Name] = err
+			return nil
+		}
+		results = append(results, r)
+		return nil
+	})
+	return results, errs, err
+}
+
+func (c *checker) check(ctx context.Context, av catalogapptests.AppVersions) (Result, error) {
+	current := catalogapptests.LatestVersion(av.Versions)
+	result := Result{App: av.Name, Current: current}
+	versionPath, err := c.catalog.PathToApp(av.Name, current)
+	if err != nil {
+		return result, err
+	}
+	src, err := ResolveSource(c.catalog, av.Name, versionPath)
+	if err != nil {
+		return result, err
+	}
+	result.Source = src
+
+	lister := c.oci
+	if src.Kind == SourceKindHelm {
+		lister = c.helm
+	}
+	tags, err := lister.ListTags(ctx, src)
+	if err != nil {
+		return result, err
+	}
+	upstream := c.semverTags(tags)
+	if len(upstream) == 0 {
+		return result, fmt.Errorf("no semver versions found at %s", src.URL)
+	}
+	result.Latest = strings.TrimPrefix(upstream[len(upstream)-1].Original(), "v")
+
+	cur, err := semver.NewVersion(current)
+	if err != nil {
+		// Non-semver catalog version: only the latest upstream version can be recommended.
+		if result.Latest != current {
+			result.Newer = []string{result.Latest}
+		}
+		return result, nil
+	}
+	for _, v := range upstream {
+		if v.GreaterThan(cur) {
+			result.Newer = append(result.Newer, strings.TrimPrefix(v.Original(), "v"))
+		}
+	}
+	return result, nil
+}
+
+// semverTags parses tags as semver (a leading "v" is accepted), dropping non-version tags
+// such as "latest" and, unless enabled, pre-releases. The result is sorted ascending.
+func (c *checker) semverTags(tags []string) []*semver.Version {
+	var out []*semver.Version
+	seen := make(map[string]bool)
+	for _, t := range tags {
+		v, err := semver.NewVersion(t)
+		if err != nil || seen[v.String()] {
+			continue
+		}
+		if v.Prerelease() != "" && !c.includePrereleases {
+			continue
+		}
+		seen[v.String()] = true
+		out = append(out, v)
+	}
+	sort.Sort(semver.Collection(out))
+	return out
+}
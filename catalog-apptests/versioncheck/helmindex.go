@@ -0,0 +1,69 @@
+package versioncheck
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+// Ensure helmIndex implements TagLister at compile time.
+var _ TagLister = (*helmIndex)(nil)
+
+// helmIndex lists chart versions from a Helm repository's index.yaml.
+type helmIndex struct {
+	client *http.Client
+}
+
+// NewHelmIndex returns a TagLister for Helm repository sources. A nil client uses http.DefaultClient.
+func NewHelmIndex(client *http.Client) TagLister {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &helmIndex{client: client}
+}
+
+type indexFile struct {
+	Entries map[string][]struct {
+		Version string `json:"version"`
+	} `json:"entries"`
+}
+
+func (h *helmIndex) ListTags(ctx context.Context, src *Source) ([]string, error) {
+	if src.Kind != SourceKindHelm {
+		return nil, fmt.Errorf("not a Helm repository source: %s", src.URL)
+	}
+	u := strings.TrimSuffix(src.URL, "/") + "/index.yaml"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch %s: %s", u, resp.Status)
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var idx indexFile
+	if err := sigsyaml.Unmarshal(b, &idx); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", u, err)
+	}
+	entries, ok := idx.Entries[src.Chart]
+	if !ok {
+		return nil, fmt.Errorf("chart %s not found in %s", src.Chart, u)
+	}
+	versions := make([]string, 0, len(entries))
+	for _, e := range entries {
+		versions = append(versions, e.Version)
+	}
+	return versions, nil
+}
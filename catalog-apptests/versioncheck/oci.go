@@ -0,0 +1,178 @@
+package versioncheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// TagLister lists the upstream versions (tags) available for a chart source.
+type TagLister interface {
+	ListTags(ctx context.Context, src *Source) ([]string, error)
+}
+
+// Ensure ociRegistry implements TagLister at compile time.
+var _ TagLister = (*ociRegistry)(nil)
+
+// ociRegistry lists tags via the OCI distribution API (GET /v2/<name>/tags/list), handling anonymous
+// bearer-token challenges (ghcr.io, docker.io, quay.io) and Link-header pagination.
+type ociRegistry struct {
+	client *http.Client
+}
+
+// NewOCIRegistry returns a TagLister for OCI sources. A nil client uses http.DefaultClient.
+func NewOCIRegistry(client *http.Client) TagLister {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &ociRegistry{client: client}
+}
+
+type tagsResponse struct {
+	Tags []string `json:"tags"`
+}
+
+func (r *ociRegistry) ListTags(ctx context.Context, src *Source) ([]string, error) {
+	if src.Kind != SourceKindOCI {
+		return nil, fmt.Errorf("not an OCI source: %s", src.URL)
+	}
+	host, repo, err := splitOCIURL(src.URL)
+	if err != nil {
+		return nil, err
+	}
+	scheme := "https"
+	if src.Insecure {
+		scheme = "http"
+	}
+	next := fmt.Sprintf("%s://%s/v2/%s/tags/list", scheme, host, repo)
+	var tags []string
+	token := ""
+	for next != "" {
+		resp, err := r.get(ctx, next, token)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusUnauthorized && token == "" {
+			challenge := resp.Header.Get("WWW-Authenticate")
+			_ = resp.Body.Close()
+			token, err = r.fetchToken(ctx, challenge, repo)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+			_ = resp.Body.Close()
+			return nil, fmt.Errorf("tags/list %s: %s: %s", src.URL, resp.Status, strings.TrimSpace(string(body)))
+		}
+		var page tagsResponse
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		link := resp.Header.Get("Link")
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("decode tags/list %s: %w", src.URL, err)
+		}
+		tags = append(tags, page.Tags...)
+		next, err = nextPage(next, link)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return tags, nil
+}
+
+func (r *ociRegistry) get(ctx context.Context, u, token string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return r.client.Do(req)
+}
+
+var challengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// fetchToken requests an anonymous pull token from the realm named in a Bearer challenge.
+func (r *ociRegistry) fetchToken(ctx context.Context, challenge, repo string) (string, error) {
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return "", fmt.Errorf("unsupported registry auth challenge: %q", challenge)
+	}
+	params := map[string]string{}
+	for _, m := range challengeParam.FindAllStringSubmatch(challenge, -1) {
+		params[strings.ToLower(m[1])] = m[2]
+	}
+	realm := params["realm"]
+	if realm == "" {
+		return "", fmt.Errorf("registry auth challenge has no realm: %q", challenge)
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = "repository:" + repo + ":pull"
+	}
+	q := url.Values{}
+	q.Set("scope", scope)
+	if s := params["service"]; s != "" {
+		q.Set("service", s)
+	}
+	resp, err := r.get(ctx, realm+"?"+q.Encode(), "")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry token %s: %s", realm, resp.Status)
+	}
+	var tok struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil {
+		return "", fmt.Errorf("decode registry token: %w", err)
+	}
+	if tok.Token != "" {
+		return tok.Token, nil
+	}
+	return tok.AccessToken, nil
+}
+
+// splitOCIURL splits oci://host/path into registry host and repository name.
+// docker.io is mapped to its registry endpoint registry-1.docker.io.
+func splitOCIURL(ociURL string) (string, string, error) {
+	rest := strings.TrimPrefix(ociURL, "oci://")
+	host, repo, ok := strings.Cut(rest, "/")
+	if !ok || host == "" || repo == "" {
+		return "", "", fmt.Errorf("invalid OCI URL: %s", ociURL)
+	}
+	if host == "docker.io" {
+		host = "registry-1.docker.io"
+	}
+	return host, strings.TrimSuffix(repo, "/"), nil
+}
+
+var linkNext = regexp.MustCompile(`<([^>]+)>\s*;\s*rel="?next"?`)
+
+// nextPage resolves the rel="next" Link header (possibly relative) against the current page URL.
+func nextPage(current, link string) (string, error) {
+	m := linkNext.FindStringSubmatch(link)
+	if m == nil {
+		return "", nil
+	}
+	base, err := url.Parse(current)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(m[1])
+	if err != nil {
+		return "", err
+	}
+	return base.ResolveReference(ref).String(), nil
+}
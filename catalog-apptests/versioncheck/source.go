@@ -0,0 +1,63 @@
+package versioncheck
+
+import (
+	"strings"
+
+	catalogapptests "github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests"
+)
+
+// SourceKind is where upstream chart versions are listed from.
+type SourceKind string
+
+const (
+	SourceKindOCI  SourceKind = "oci"  // OCI distribution API tags/list
+	SourceKindHelm SourceKind = "helm" // Helm repository index.yaml
+)
+
+// Source is the upstream chart location of an app.
+type Source struct {
+	Kind SourceKind
+	// URL is oci://host/path for OCI sources, or the Helm repository URL for Helm sources.
+	URL string
+	// Chart is the chart name within a Helm repository (Helm sources only).
+	Chart string
+	// Insecure uses plain HTTP for OCI registries (OCIRepository spec.insecure).
+	Insecure bool
+	// HelmRepo and OCIPush are set when the source comes from .catalog-source.yaml.
+	HelmRepo string
+	OCIPush  string
+}
+
+// ErrNoSource is returned when neither .catalog-source.yaml nor the helmrelease declares a chart source.
+var ErrNoSource = catalogapptests.ErrNoChartRef
+
+// ResolveSource returns the upstream chart source of an app. The app's .catalog-source.yaml takes precedence
+// (chart is pulled from a Helm repo, then pushed to OCI); otherwise the OCIRepository or HelmRepository
+// in versionPath/helmrelease/helmrelease.yaml is used.
+func ResolveSource(cat catalogapptests.Catalog, appName, versionPath string) (*Source, error) {
+	cs, err := cat.CatalogSource(appName)
+	if err != nil {
+		return nil, err
+	}
+	if cs != nil {
+		return &Source{
+			Kind:     SourceKindHelm,
+			URL:      cs.HelmRepoURL,
+			Chart:    cs.ChartName(),
+			HelmRepo: cs.HelmRepo,
+			OCIPush:  cs.OCIPush,
+		}, nil
+	}
+	ref, err := catalogapptests.LoadChartRef(versionPath)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case ref.Kind == "OCIRepository":
+		return &Source{Kind: SourceKindOCI, URL: ref.URL, Insecure: ref.Insecure}, nil
+	case ref.Type == "oci":
+		return &Source{Kind: SourceKindOCI, URL: strings.TrimSuffix(ref.URL, "/") + "/" + ref.Chart, Insecure: ref.Insecure}, nil
+	default:
+		return &Source{Kind: SourceKindHelm, URL: ref.URL, Chart: ref.Chart}, nil
+	}
+}
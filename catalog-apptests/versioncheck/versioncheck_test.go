@@ -0,0 +1,139 @@
+package versioncheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	catalogapptests "github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests"
+	"github.com/deepak-muley/dm-nkp-gitops-app-catalog/catalog-apptests/internal/testutil"
+)
+
+func TestVersionCheck(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Version Check Suite")
+}
+
+// newRegistry serves /v2/<repo>/tags/list behind an anonymous bearer-token challenge, two tags per page.
+func newRegistry(repo string, tags []string) *httptest.Server {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			_ = json.NewEncoder(w).Encode(map[string]string{"token": "anon"})
+		case r.URL.Path == "/v2/"+repo+"/tags/list":
+			if r.Header.Get("Authorization") != "Bearer anon" {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test",scope="repository:%s:pull"`, srv.URL, repo))
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			page := tags
+			if r.URL.Query().Get("last") == "" && len(tags) > 2 {
+				page = tags[:2]
+				w.Header().Set("Link", fmt.Sprintf(`</v2/%s/tags/list?last=%s>; rel="next"`, repo, tags[1]))
+			} else if r.URL.Query().Get("last") != "" {
+				page = tags[2:]
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"name": repo, "tags": page})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	return srv
+}
+
+var _ = Describe("Checker", func() {
+	var appsDir string
+
+	BeforeEach(func() {
+		appsDir = GinkgoT().TempDir()
+	})
+
+	It("reports newer semver tags from an OCI registry", func() {
+		reg := newRegistry("charts/podinfo", []string{"6.9.3", "latest", "6.9.4", "v6.10.0", "6.11.0-rc.1"})
+		defer reg.Close()
+		host := strings.TrimPrefix(reg.URL, "http://")
+		for _, v := range []string{"6.9.3", "6.9.4"} {
+			testutil.WriteFile(filepath.Join(appsDir, "podinfo", v, "metadata.yaml"), "displayName: Podinfo\n")
+			testutil.WriteFile(filepath.Join(appsDir, "podinfo", v, "helmrelease", "helmrelease.yaml"), fmt.Sprintf(`apiVersion: source.toolkit.fluxcd.io/v1
+kind: OCIRepository
+metadata:
+  name: ${releaseName}-chart
+spec:
+  insecure: true
+  ref:
+    tag: %s
+  url: oci://%s/charts/podinfo
+---
+apiVersion: helm.toolkit.fluxcd.io/v2
+kind: HelmRelease
+metadata:
+  name: podinfo
+`, v, host))
+		}
+		cat, err := catalogapptests.NewCatalogAt(appsDir)
+		Expect(err).ToNot(HaveOccurred())
+
+		r, err := NewChecker(cat).Check(context.Background(), "podinfo")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(r.Current).To(Equal("6.9.4"))
+		Expect(r.Latest).To(Equal("6.10.0"))
+		Expect(r.Newer).To(Equal([]string{"6.10.0"}))
+		Expect(r.Command()).To(ContainSubstring("--ocirepo oci://" + host + "/charts/podinfo"))
+		Expect(r.Command()).To(ContainSubstring("--version 6.10.0 "))
+
+		r, err = NewChecker(cat, WithPrereleases()).Check(context.Background(), "podinfo")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(r.Newer).To(Equal([]string{"6.10.0", "6.11.0-rc.1"}))
+	})
+
+	It("prefers .catalog-source.yaml and reads the Helm index", func() {
+		index := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/index.yaml" {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write([]byte(`apiVersion: v1
+entries:
+  vault:
+  - version: 0.32.0
+  - version: 0.31.0
+  - version: 0.30.1
+  other:
+  - version: 9.9.9
+`))
+		}))
+		defer index.Close()
+		testutil.WriteFile(filepath.Join(appsDir, "vault", ".catalog-source.yaml"), fmt.Sprintf(`helmrepo: hashicorp/vault
+helmrepoUrl: %s
+ocipush: oci://ghcr.io/example/vault
+`, index.URL))
+		testutil.WriteFile(filepath.Join(appsDir, "vault", "0.31.0", "metadata.yaml"), "displayName: Vault\n")
+		cat, err := catalogapptests.NewCatalogAt(appsDir)
+		Expect(err).ToNot(HaveOccurred())
+
+		results, errs, err := NewChecker(cat).CheckAll(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(errs).To(BeEmpty())
+		Expect(results).To(HaveLen(1))
+		Expect(results[0].Latest).To(Equal("0.32.0"))
+		Expect(results[0].Source.Kind).To(Equal(SourceKindHelm))
+		Expect(results[0].Command()).To(Equal("./catalog-workflow.sh add-app --appname vault --version 0.32.0 --helmrepo hashicorp/vault --ocipush oci://ghcr.io/example/vault --helmrepo-url " + index.URL))
+	})
+
+	It("reports apps without a chart source", func() {
+		testutil.WriteFile(filepath.Join(appsDir, "custom", "1.0.0", "helmrelease", "helmrelease.yaml"), "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: x\n")
+		cat, err := catalogapptests.NewCatalogAt(appsDir)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = NewChecker(cat).Check(context.Background(), "custom")
+		Expect(err).To(MatchError(ErrNoSource))
+	})
+})